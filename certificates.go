@@ -0,0 +1,184 @@
+package gordon
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pion/dtls/v2"
+)
+
+const (
+	// DirectoryCertificateFile and DirectoryKeyFile are the names of the
+	// certificate and key files a DirectoryCertificateSource looks for,
+	// matching the layout of a kubernetes TLS secret
+	DirectoryCertificateFile = "tls.crt"
+	DirectoryKeyFile         = "tls.key"
+)
+
+// A CertificateSource provides the certificate a Listener presents to
+// clients during each DTLS handshake, allowing certificates to change
+// without restarting the Listener
+type CertificateSource interface {
+	GetCertificate(*dtls.ClientHelloInfo) (*tls.Certificate, error)
+}
+
+// StaticCertificate is a CertificateSource which always provides the same
+// certificate
+type StaticCertificate tls.Certificate
+
+// GetCertificate fulfills the CertificateSource interface
+func (c StaticCertificate) GetCertificate(*dtls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert := tls.Certificate(c)
+
+	return &cert, nil
+}
+
+// A FileCertificateSource is a CertificateSource which loads a PEM encoded
+// certificate and key from disk.
+//
+// Handshakes are always served from memory; certificates are reloaded from
+// disk either by calling Reload directly, such as on SIGHUP, or by running
+// Watch in the background, which polls the files for changes. Where a reload
+// fails, such as when the certificate has been replaced but the key hasn't
+// yet, the previous certificate carries on being used until a reload
+// succeeds.
+//
+// A FileCertificateSource should be created with NewFileCertificateSource
+// or NewDirectoryCertificateSource
+type FileCertificateSource struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// NewFileCertificateSource loads the PEM encoded certificate and key in
+// certFile and keyFile, returning an error if they can't be loaded
+func NewFileCertificateSource(certFile, keyFile string) (s *FileCertificateSource, err error) {
+	s = &FileCertificateSource{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	err = s.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// NewDirectoryCertificateSource loads the PEM encoded certificate and key
+// in dir, named DirectoryCertificateFile and DirectoryKeyFile respectively,
+// in the same way as NewFileCertificateSource.
+//
+// This is handy for certificates which are rotated by replacing the
+// contents of a directory, such as those managed by kubernetes or certbot.
+// Symlinks are followed, and so a directory whose files are swapped out
+// from underneath it, as kubernetes does, is picked up by Watch
+func NewDirectoryCertificateSource(dir string) (*FileCertificateSource, error) {
+	return NewFileCertificateSource(
+		filepath.Join(dir, DirectoryCertificateFile),
+		filepath.Join(dir, DirectoryKeyFile),
+	)
+}
+
+// GetCertificate fulfills the CertificateSource interface, returning the
+// most recently loaded certificate
+func (s *FileCertificateSource) GetCertificate(*dtls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.cert, nil
+}
+
+// Watch checks the certificate and key for changes every interval, reloading
+// them when either changes, until ctx is cancelled.
+//
+// Watch blocks, and so should be run in its own goroutine, as per
+//
+//	src, _ := gordon.NewDirectoryCertificateSource("/etc/gordon/tls")
+//	go src.Watch(ctx, time.Minute)
+func (s *FileCertificateSource) Watch(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-t.C:
+			s.reloadIfChanged()
+		}
+	}
+}
+
+// Reload reads the certificate and key from disk, replacing the current
+// certificate if they're valid
+func (s *FileCertificateSource) Reload() (err error) {
+	// Read the modification time before the files themselves, so that
+	// a change made while we're reading is picked up next time
+	modTime, err := s.latestModTime()
+	if err != nil {
+		return
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cert = &cert
+	s.modTime = modTime
+
+	return
+}
+
+// reloadIfChanged reloads the certificate and key if either has changed
+// since they were last loaded
+func (s *FileCertificateSource) reloadIfChanged() {
+	modTime, err := s.latestModTime()
+	if err != nil {
+		return
+	}
+
+	s.mu.RLock()
+	changed := !modTime.Equal(s.modTime)
+	s.mu.RUnlock()
+
+	if changed {
+		// Errors are deliberately ignored; we carry on serving the
+		// previous certificate until we can load a new one
+		//#nosec: G104
+		s.Reload()
+	}
+}
+
+func (s *FileCertificateSource) latestModTime() (t time.Time, err error) {
+	for _, f := range []string{s.certFile, s.keyFile} {
+		var fi os.FileInfo
+
+		// Stat, rather than Lstat, so that symlinks swapped
+		// underneath us are followed
+		fi, err = os.Stat(f)
+		if err != nil {
+			return
+		}
+
+		if fi.ModTime().After(t) {
+			t = fi.ModTime()
+		}
+	}
+
+	return
+}
@@ -0,0 +1,144 @@
+package gordon
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pion/dtls/v2/pkg/crypto/selfsign"
+)
+
+// writeCertificate generates a self-signed certificate, writes it to dir
+// in PEM format, and returns its DER encoding
+func writeCertificate(t *testing.T, dir string, modTime time.Time) []byte {
+	t.Helper()
+
+	cert, err := selfsign.GenerateSelfSigned()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for f, block := range map[string]*pem.Block{
+		DirectoryCertificateFile: {Type: "CERTIFICATE", Bytes: cert.Certificate[0]},
+		DirectoryKeyFile:         {Type: "PRIVATE KEY", Bytes: key},
+	} {
+		path := filepath.Join(dir, f)
+
+		err = os.WriteFile(path, pem.EncodeToMemory(block), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.Chtimes(path, modTime, modTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return cert.Certificate[0]
+}
+
+func TestStaticCertificate(t *testing.T) {
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	rcvd, err := StaticCertificate(cert).GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(cert.Certificate[0], rcvd.Certificate[0]) {
+		t.Error("unexpected certificate returned")
+	}
+}
+
+func TestNewFileCertificateSource_MissingFiles(t *testing.T) {
+	_, err := NewDirectoryCertificateSource(t.TempDir())
+	if err == nil {
+		t.Error("expected error, received none")
+	}
+}
+
+func TestFileCertificateSource_Watch(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	original := writeCertificate(t, dir, now.Add(-time.Hour))
+
+	s, err := NewDirectoryCertificateSource(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// expectCertificate waits for s to serve expect, failing the test
+	// if it hasn't within a second
+	expectCertificate := func(t *testing.T, expect []byte) {
+		t.Helper()
+
+		for i := 0; i < 100; i++ {
+			rcvd, err := s.GetCertificate(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if bytes.Equal(expect, rcvd.Certificate[0]) {
+				return
+			}
+
+			time.Sleep(time.Millisecond * 10)
+		}
+
+		t.Error("unexpected certificate returned")
+	}
+
+	t.Run("Initial certificates are served", func(t *testing.T) {
+		expectCertificate(t, original)
+	})
+
+	rotated := writeCertificate(t, dir, now)
+
+	t.Run("Certificates are not reloaded during handshakes", func(t *testing.T) {
+		rcvd, err := s.GetCertificate(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(original, rcvd.Certificate[0]) {
+			t.Error("unexpected certificate returned")
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go s.Watch(ctx, time.Millisecond*10)
+
+	t.Run("Rotated certificates are reloaded", func(t *testing.T) {
+		expectCertificate(t, rotated)
+	})
+
+	err = os.WriteFile(filepath.Join(dir, DirectoryKeyFile), []byte("not a key"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Chtimes(filepath.Join(dir, DirectoryKeyFile), now.Add(time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Broken certificates are ignored", func(t *testing.T) {
+		time.Sleep(time.Millisecond * 50)
+
+		expectCertificate(t, rotated)
+	})
+}
@@ -13,8 +13,10 @@ import (
 type Address struct {
 	orig string
 
-	addr  *net.UDPAddr
-	docID uuid.UUID
+	host     string
+	hostPort string
+	addr     *net.UDPAddr
+	docID    uuid.UUID
 }
 
 func (a Address) String() string {
@@ -22,13 +24,27 @@ func (a Address) String() string {
 }
 
 func (a Address) Server() string {
+	if a.addr == nil {
+		return ""
+	}
+
 	return a.addr.AddrPort().String()
 }
 
+// Host returns the host and port of the server as written, rather than
+// as resolved by Server
+func (a Address) Host() string {
+	return a.hostPort
+}
+
 func (a Address) Page() string {
 	return a.docID.String()
 }
 
+func (a Address) ID() uuid.UUID {
+	return a.docID
+}
+
 func ParseAddress(s string) (a Address, err error) {
 	a.orig = s
 
@@ -42,6 +58,9 @@ func ParseAddress(s string) (a Address, err error) {
 		u.Host += ":4444"
 	}
 
+	a.host = u.Hostname()
+	a.hostPort = u.Host
+
 	a.addr, err = net.ResolveUDPAddr("udp", u.Host)
 	if err != nil {
 		return
@@ -57,6 +76,24 @@ func ParseAddress(s string) (a Address, err error) {
 	return
 }
 
+// withID returns a with its page ID set to id
+func (a Address) withID(id uuid.UUID) Address {
+	a.docID = id
+
+	return a
+}
+
+// resolve returns the address of the page ref points to, which is on the
+// same server as a unless ref.Server says otherwise
+func (a Address) resolve(ref types.PageRef) (Address, error) {
+	server := ref.Server
+	if server == "" {
+		server = a.hostPort
+	}
+
+	return ParseAddress("//" + server + "/" + ref.Page.String())
+}
+
 func ParseVerb(s string) (types.Verb, error) {
 	switch strings.ToLower(s) {
 	case "create":
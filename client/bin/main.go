@@ -1,15 +1,27 @@
 package main
 
 import (
+	"context"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"os"
+	"time"
 
 	"github.com/jspc/gordon/client"
-	"github.com/kr/pretty"
+	"github.com/jspc/gordon/render"
+	"github.com/jspc/gordon/types"
 )
 
 var (
-	verb = flag.String("X", "READ", "Verb to use during rquest, case insensitive")
+	verb    = flag.String("X", "READ", "Verb to use during rquest, case insensitive")
+	timeout = flag.Duration("t", time.Second*10, "Time to wait for a response")
+	hosts   = flag.String("known-hosts", "", "Known hosts file to pin server certificates in (default ~/.gordon/known_hosts)")
+	replace = flag.Bool("replace-pin", false, "Trust the server's current certificate, even if it doesn't match known hosts")
+	pskID   = flag.String("psk-identity", "", "Identity to authenticate with, using the pre-shared key in -psk-key, rather than certificates")
+	pskKey  = flag.String("psk-key", "", "Hex encoded pre-shared key to authenticate with, when -psk-identity is set")
+	follow  = flag.Int("max-redirects", 10, "Most redirects to follow; 0 disables following redirects")
+	format  = flag.String("format", "", "Format to print pages in; one of text, ansi, markdown, html, or gemtext (default ansi on terminals, otherwise text)")
 )
 
 func main() {
@@ -29,11 +41,86 @@ func main() {
 		panic(err)
 	}
 
-	page, err := client.DoRequest(v, addr)
+	f, err := outputFormat()
 	if err != nil {
 		panic(err)
 	}
 
-	//#nosec: G104
-	pretty.Print(page)
+	c, err := client.NewClient()
+	if err != nil {
+		panic(err)
+	}
+
+	c.ReadTimeout = *timeout
+	c.MaxRedirects = *follow
+
+	if *hosts == "" {
+		*hosts, err = client.DefaultKnownHostsPath()
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	c.KnownHosts, err = client.LoadKnownHosts(*hosts)
+	if err != nil {
+		panic(err)
+	}
+
+	if *pskID != "" {
+		key, err := hex.DecodeString(*pskKey)
+		if err != nil {
+			panic(err)
+		}
+
+		c.PSKIdentity = []byte(*pskID)
+		c.PSK = func([]byte) ([]byte, error) {
+			return key, nil
+		}
+	}
+
+	req := &types.Request{
+		Verb: v,
+		ID:   addr.ID(),
+	}
+
+	page, err := c.Do(context.Background(), addr, req)
+
+	var mismatch client.CertificateMismatchError
+	if errors.As(err, &mismatch) && *replace {
+		err = c.KnownHosts.Accept(mismatch.Server, mismatch.Received)
+		if err != nil {
+			panic(err)
+		}
+
+		page, err = c.Do(context.Background(), addr, req)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	r := render.Renderer{
+		Format: f,
+		Server: addr.Host(),
+	}
+
+	err = r.Render(os.Stdout, page)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// outputFormat returns the format set with -format or, where unset, ansi
+// where stdout is a terminal and text where it isn't
+func outputFormat() (render.Format, error) {
+	if *format != "" {
+		return render.ParseFormat(*format)
+	}
+
+	fi, err := os.Stdout.Stat()
+	if err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		return render.FormatANSI, nil
+	}
+
+	return render.FormatText, nil
 }
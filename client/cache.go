@@ -0,0 +1,253 @@
+package client
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jspc/gordon/types"
+)
+
+const (
+	defaultCacheTTL     = time.Minute * 5
+	defaultCacheMaxSize = 64 * 1024 * 1024
+)
+
+// A Cache stores pages read by a Client, keyed by server and page ID, so
+// that repeated reads of the same page needn't go to the server at all.
+//
+// Pages are served from the Cache for TTL after they were fetched. After
+// that they're stale, and the server is asked whether they've changed
+// with a conditional read: where they haven't, the server answers with a
+// few bytes rather than the whole page, and the cached page is used again.
+//
+// Only pages with a status of types.StatusOK are cached.
+//
+// Pages returned from a Cache are shared between every caller which reads
+// them, and so must not be modified.
+//
+// A Cache is safe to use from multiple goroutines, and should be created
+// with NewCache. Fields may be overwritten after NewCache is called, but
+// not once the Cache is in use
+type Cache struct {
+	// TTL is how long pages are served from the Cache before they're
+	// revalidated with the server
+	TTL time.Duration
+
+	// MaxSize is the most the Cache holds, in bytes of marshalled pages.
+	// Once full, the least recently used pages are evicted to make room.
+	// Pages larger than MaxSize aren't cached at all
+	MaxSize int
+
+	// Dir, where set, is a directory pages are also written to, so that
+	// the Cache survives between runs. Pages on disk are loaded the first
+	// time they're asked for, and removed when evicted
+	Dir string
+
+	mu      sync.Mutex
+	size    int
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key     string
+	page    *types.Page
+	size    int
+	fetched time.Time
+}
+
+// NewCache returns an empty, in memory, Cache with a TTL of 5 minutes and
+// a MaxSize of 64MiB
+func NewCache() *Cache {
+	return &Cache{
+		TTL:     defaultCacheTTL,
+		MaxSize: defaultCacheMaxSize,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Remove drops the page at addr from the Cache, if it's there
+func (c *Cache) Remove(addr Address) error {
+	key := cacheKey(addr)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.evict(e)
+	}
+
+	return c.removeFile(key)
+}
+
+// get returns the page cached for key, if there is one, and whether it's
+// still fresh
+func (c *Cache) get(key string) (page *types.Page, fresh bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		e, ok = c.load(key)
+		if !ok {
+			return
+		}
+	}
+
+	c.lru.MoveToFront(e)
+
+	entry := e.Value.(*cacheEntry)
+
+	return entry.page, time.Since(entry.fetched) < c.TTL
+}
+
+// put caches page for key, as having been fetched just now, writing it to
+// disk where Dir is set
+func (c *Cache) put(key string, page *types.Page) (err error) {
+	buf := new(bytes.Buffer)
+
+	err = page.Marshall(buf)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fetched := time.Now()
+
+	err = c.insert(key, page, buf.Len(), fetched)
+	if c.Dir == "" {
+		return
+	}
+
+	// Pages too large to cache replace whatever was cached before
+	// them, and so that has to go too
+	if _, ok := c.entries[key]; !ok {
+		return errors.Join(err, c.removeFile(key))
+	}
+
+	return errors.Join(err, c.writeFile(key, buf.Bytes(), fetched))
+}
+
+// insert adds page, which marshalls to size bytes, to the Cache in memory,
+// evicting the least recently used pages to make room. It must be called
+// with mu held
+func (c *Cache) insert(key string, page *types.Page, size int, fetched time.Time) (err error) {
+	if e, ok := c.entries[key]; ok {
+		c.evict(e)
+	}
+
+	if size > c.MaxSize {
+		return
+	}
+
+	for c.size+size > c.MaxSize {
+		oldest := c.lru.Back()
+
+		err = errors.Join(err, c.removeFile(oldest.Value.(*cacheEntry).key))
+		c.evict(oldest)
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{
+		key:     key,
+		page:    page,
+		size:    size,
+		fetched: fetched,
+	})
+
+	c.size += size
+
+	return
+}
+
+// evict removes e from memory, and must be called with mu held
+func (c *Cache) evict(e *list.Element) {
+	entry := c.lru.Remove(e).(*cacheEntry)
+
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// load reads the page for key from disk, where Dir is set, adding it to the
+// Cache in memory. It must be called with mu held
+func (c *Cache) load(key string) (e *list.Element, ok bool) {
+	if c.Dir == "" {
+		return
+	}
+
+	//#nosec: G304
+	data, err := os.ReadFile(c.path(key))
+	if err != nil || len(data) < 8 {
+		return
+	}
+
+	// Files start with the time their page was fetched, in nanoseconds
+	// since the epoch, followed by the marshalled page
+	//#nosec: G115
+	fetched := time.Unix(0, int64(binary.BigEndian.Uint64(data)))
+	data = data[8:]
+
+	page := new(types.Page)
+
+	err = page.Unmarshall(bytes.NewBuffer(data))
+	if err != nil {
+		return
+	}
+
+	//#nosec: G104
+	c.insert(key, page, len(data), fetched)
+
+	e, ok = c.entries[key]
+
+	return
+}
+
+func (c *Cache) writeFile(key string, data []byte, fetched time.Time) (err error) {
+	if c.Dir == "" {
+		return
+	}
+
+	buf := make([]byte, 8, 8+len(data))
+
+	//#nosec: G115
+	binary.BigEndian.PutUint64(buf, uint64(fetched.UnixNano()))
+
+	return writeFile(c.path(key), append(buf, data...))
+}
+
+func (c *Cache) removeFile(key string) error {
+	if c.Dir == "" {
+		return nil
+	}
+
+	err := os.Remove(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// path returns the file the page for key is written to, named for a hash
+// of key, since keys contain characters which aren't safe in filenames
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:]))
+}
+
+// cacheKey returns the key pages read from addr are cached under
+func cacheKey(addr Address) string {
+	return redirectKey(addr, addr.docID)
+}
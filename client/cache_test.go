@@ -0,0 +1,184 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jspc/gordon/types"
+)
+
+func cachePage(title string) *types.Page {
+	return &types.Page{
+		Title:  title,
+		Status: types.StatusOK,
+		Meta: types.Metadata{
+			Revision:  "r1",
+			Published: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		},
+		Sections: []types.Section{
+			{Title: "Chapter 1", Body: strings.Repeat("a", 100)},
+		},
+	}
+}
+
+func TestCache_Eviction(t *testing.T) {
+	c := NewCache()
+
+	// Room for two of our pages, but not three
+	c.MaxSize = 600
+
+	for _, key := range []string{"a", "b"} {
+		err := c.put(key, cachePage(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Reading a makes b the least recently used
+	c.get("a")
+
+	err := c.put("c", cachePage("c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, expect := range map[string]bool{"a": true, "b": false, "c": true} {
+		page, _ := c.get(key)
+		if (page != nil) != expect {
+			t.Errorf("%s: expected cached to be %v, received %#v", key, expect, page)
+		}
+	}
+
+	c.MaxSize = 10
+
+	err = c.put("d", cachePage("d"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if page, _ := c.get("d"); page != nil {
+		t.Errorf("expected page larger than MaxSize to be skipped, received %#v", page)
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	c := NewCache()
+
+	//#nosec: G104
+	c.put("a", cachePage("a"))
+
+	if _, fresh := c.get("a"); !fresh {
+		t.Error("expected page to be fresh")
+	}
+
+	c.TTL = 0
+
+	if _, fresh := c.get("a"); fresh {
+		t.Error("expected page to be stale")
+	}
+}
+
+func TestCache_Dir(t *testing.T) {
+	dir := t.TempDir()
+
+	c := NewCache()
+	c.Dir = dir
+
+	err := c.put("a", cachePage("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c = NewCache()
+	c.Dir = dir
+
+	page, fresh := c.get("a")
+	if page == nil || page.Title != "a" {
+		t.Fatalf("expected page to be loaded from disk, received %#v", page)
+	}
+
+	if !fresh {
+		t.Error("expected page loaded from disk to still be fresh")
+	}
+
+	addr, _ := ParseAddress("//localhost:4483/" + uuid.Nil.String())
+
+	err = c.put(cacheKey(addr), cachePage("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Remove(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if page, _ := NewCache().get(cacheKey(addr)); page != nil {
+		t.Errorf("expected removed page to be gone from disk, received %#v", page)
+	}
+}
+
+func TestClient_Get_Cache(t *testing.T) {
+	var full, notModified atomic.Int64
+
+	addr := startServer(t, func(ctx context.Context, req *types.Request) (*types.Page, error) {
+		if req.Args[types.ArgIfNoneMatch] == "r1" {
+			notModified.Add(1)
+		} else {
+			full.Add(1)
+		}
+
+		return cachePage("A Cached Page"), nil
+	}, "localhost:4483")
+
+	c, _ := NewClient()
+	c.Cache = NewCache()
+
+	get := func() {
+		t.Helper()
+
+		page, err := c.Get(context.Background(), addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if page.Title != "A Cached Page" {
+			t.Errorf("unexpected page %#v", page)
+		}
+	}
+
+	expect := func(expectFull, expectNotModified int64) {
+		t.Helper()
+
+		if full.Load() != expectFull || notModified.Load() != expectNotModified {
+			t.Errorf("expected %d full and %d conditional reads, received %d and %d",
+				expectFull, expectNotModified, full.Load(), notModified.Load(),
+			)
+		}
+	}
+
+	get()
+	get()
+	expect(1, 0)
+
+	// Stale pages are revalidated, rather than read again
+	c.Cache.TTL = 0
+
+	get()
+	expect(1, 1)
+
+	// Anything other than a read drops the page from the cache
+	c.Cache.TTL = time.Hour
+
+	_, err := c.Do(context.Background(), addr, &types.Request{Verb: types.VerbUpdate, ID: addr.ID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get()
+	expect(3, 1)
+}
@@ -4,70 +4,399 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log/slog"
+	"net"
 	"time"
 
+	"github.com/jspc/gordon/frame"
+	"github.com/jspc/gordon/internal/psk"
 	"github.com/jspc/gordon/types"
 	"github.com/pion/dtls/v2"
 	"github.com/pion/dtls/v2/pkg/crypto/selfsign"
 )
 
+const (
+	defaultDialTimeout = time.Second * 5
+	defaultReadTimeout = time.Second * 10
+
+	// readBufferSize is the size of the socket receive buffer the client
+	// asks for, which operating systems may cap to something smaller
+	readBufferSize = 4 * 1024 * 1024
+
+	// DefaultMaxResponseSize is the largest response, in bytes, a Client
+	// created with NewClient will accept from a server
+	DefaultMaxResponseSize = 16 * 1024 * 1024
+)
+
+// A Client makes requests to gordon servers.
+//
+// A Client is safe to use from multiple goroutines, and should be created
+// once with NewClient and reused, rather than created per request. Fields
+// may be overwritten after NewClient is called, but not while requests are
+// being made
+type Client struct {
+	// Certificate is presented to servers during the DTLS handshake.
+	// NewClient generates a self-signed certificate
+	Certificate tls.Certificate
+
+	// RootCAs is the set of certificate authorities used to verify
+	// servers, when InsecureSkipVerify is false. Where nil, the system
+	// roots are used
+	RootCAs *x509.CertPool
+
+	// InsecureSkipVerify disables verification of server certificates
+	// against RootCAs, and defaults to true, since most gordon servers
+	// use self-signed certificates.
+	//
+	// VerifyPeerCertificate is still called either way
+	InsecureSkipVerify bool
+
+	// VerifyPeerCertificate, if set, is called with the certificates a
+	// server presents during the handshake, allowing for custom
+	// verification policies
+	VerifyPeerCertificate func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error
+
+	// KnownHosts, if set, pins the certificate of each server on first
+	// use, refusing to connect to servers whose certificates later change.
+	// Servers are checked against KnownHosts before VerifyPeerCertificate
+	// is called
+	KnownHosts *KnownHosts
+
+	// PSK, where set, authenticates the Client to servers with a
+	// pre-shared key rather than with certificates. It is called with
+	// the identity hint the server sends, which may be empty, and returns
+	// the key to use.
+	//
+	// Neither side presents a certificate in this mode, and so
+	// Certificate, RootCAs, InsecureSkipVerify, VerifyPeerCertificate and
+	// KnownHosts are all ignored
+	PSK func(hint []byte) ([]byte, error)
+
+	// PSKIdentity is sent to servers, where PSK is set, to tell them
+	// which key the Client is using
+	PSKIdentity []byte
+
+	// DialTimeout is the longest the Client waits for a DTLS handshake
+	// to complete. A value of zero or less disables the timeout
+	DialTimeout time.Duration
+
+	// ReadTimeout is the longest the Client waits for a response once a
+	// request has been sent. A value of zero or less disables the timeout
+	ReadTimeout time.Duration
+
+	// MaxResponseSize is the largest response, in bytes, the Client will
+	// accept. Larger responses are rejected with a frame.TooLargeError
+	// without being read in full. A value of zero or less disables the
+	// limit, allowing any server to make the Client allocate up to 4GiB
+	// per response, and so should be avoided
+	MaxResponseSize int
+
+	// Logger is used to log requests, at debug level
+	Logger *slog.Logger
+
+	// Cache, where set, stores pages read with Get, which serves them from
+	// the Cache until they go stale. Other requests for a page, such as
+	// Updates, remove it from the Cache
+	Cache *Cache
+
+	// MaxRedirects is the most redirects the Client follows for a single
+	// request, after which ErrTooManyRedirects is returned. Redirects back
+	// to a document already redirected from are refused with a
+	// RedirectLoopError. A value of zero or less disables following
+	// redirects, in which case redirect pages are returned as they are
+	MaxRedirects int
+
+	// Traceparent, where set, is called with the context of each request
+	// and returns the W3C traceparent of the span that context carries,
+	// which is sent to the server in the types.ArgTraceparent Arg so that
+	// traces carry on across servers. Requests which already set that Arg,
+	// or for which Traceparent returns an empty string, are sent as is
+	Traceparent func(ctx context.Context) string
+}
+
+// NewClient returns a Client configured with a self-signed certificate, a
+// DialTimeout of 5 seconds, a ReadTimeout of 10 seconds, a MaxResponseSize
+// of DefaultMaxResponseSize, and a Logger which discards everything
+func NewClient() (c *Client, err error) {
+	c = &Client{
+		InsecureSkipVerify: true,
+		DialTimeout:        defaultDialTimeout,
+		ReadTimeout:        defaultReadTimeout,
+		MaxResponseSize:    DefaultMaxResponseSize,
+		Logger:             slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	c.Certificate, err = selfsign.GenerateSelfSigned()
+
+	return
+}
+
+// DoRequest makes a single request for the document at addr with a newly
+// created Client. Anything making more than one request should hold
+// onto a Client instead
 func DoRequest(verb types.Verb, addr Address) (page *types.Page, err error) {
-	req := types.Request{
+	c, err := NewClient()
+	if err != nil {
+		return
+	}
+
+	return c.Do(context.Background(), addr, &types.Request{
 		Verb: verb,
 		ID:   addr.docID,
+	})
+}
+
+// Get reads the document at addr, from Cache where set
+func (c *Client) Get(ctx context.Context, addr Address) (*types.Page, error) {
+	if c.Cache != nil {
+		return c.cachedGet(ctx, addr)
 	}
 
-	buf := new(bytes.Buffer)
+	return c.Do(ctx, addr, &types.Request{
+		Verb: types.VerbRead,
+		ID:   addr.docID,
+	})
+}
+
+// cachedGet reads the document at addr from Cache where it's fresh,
+// revalidating it with the server where it's stale, and reading it from
+// the server otherwise
+func (c *Client) cachedGet(ctx context.Context, addr Address) (page *types.Page, err error) {
+	key := cacheKey(addr)
+
+	cached, fresh := c.Cache.get(key)
+	if fresh {
+		return cached, nil
+	}
+
+	if cached != nil {
+		page, err = c.GetIfModified(ctx, addr, cached.Meta)
+	} else {
+		page, err = c.Do(ctx, addr, &types.Request{
+			Verb: types.VerbRead,
+			ID:   addr.docID,
+		})
+	}
 
-	err = req.Marshall(buf)
 	if err != nil {
 		return
 	}
 
-	certificate, err := selfsign.GenerateSelfSigned()
+	switch page.Status {
+	case types.StatusNotModified:
+		page = cached
+
+	case types.StatusOK:
+
+	default:
+		return
+	}
+
+	err = c.Cache.put(key, page)
 	if err != nil {
-		panic(err)
+		// A page we couldn't cache is still a page
+		c.Logger.Debug("Cache write failed",
+			slog.String("server", addr.Server()),
+			slog.String("document", addr.Page()),
+			slog.Any("error", err),
+		)
 	}
 
-	config := &dtls.Config{
-		Certificates:         []tls.Certificate{certificate},
-		InsecureSkipVerify:   true,
-		ExtendedMasterSecret: dtls.RequireExtendedMasterSecret,
+	return page, nil
+}
+
+// GetIfModified reads the document at addr, unless the copy of it
+// described by meta is still up to date, in which case the server answers
+// with a page with a status of types.StatusNotModified instead, and
+// without any of the document's content.
+//
+// Copies are compared by meta.Revision where the server sets revisions,
+// and by meta.Published otherwise
+func (c *Client) GetIfModified(ctx context.Context, addr Address, meta types.Metadata) (*types.Page, error) {
+	args := make(map[string]string)
+
+	if meta.Revision != "" {
+		args[types.ArgIfNoneMatch] = meta.Revision
+	}
+
+	if !meta.Published.IsZero() {
+		args[types.ArgIfModifiedSince] = meta.Published.Format(time.RFC3339Nano)
+	}
+
+	return c.Do(ctx, addr, &types.Request{
+		Verb: types.VerbRead,
+		ID:   addr.docID,
+		Args: args,
+	})
+}
+
+// Do sends req to the server at addr, returning the Page the server sends
+// back. Only the server portion of addr is used; the document requested is
+// whichever req.ID points to.
+//
+// Where MaxRedirects is set, redirects are followed, and the Page returned
+// is the one at the end of them.
+//
+// Cancelling ctx abandons the request, in which case the error from ctx is
+// returned
+func (c *Client) Do(ctx context.Context, addr Address, req *types.Request) (page *types.Page, err error) {
+	if c.Cache != nil && req.Verb != types.VerbRead {
+		//#nosec: G104
+		c.Cache.Remove(addr.withID(req.ID))
+	}
+
+	page, err = c.do(ctx, addr, req)
+	if err != nil || c.MaxRedirects <= 0 || page.Status != types.StatusRedirect {
+		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
-	defer cancel()
+	return c.follow(ctx, addr, req, page)
+}
+
+// do makes a single request, without following redirects
+func (c *Client) do(ctx context.Context, addr Address, req *types.Request) (page *types.Page, err error) {
+	start := time.Now()
+
+	defer func() {
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+
+		c.Logger.Debug("Request",
+			slog.String("server", addr.Server()),
+			slog.String("document", req.ID.String()),
+			slog.Duration("duration", time.Since(start)),
+			slog.Any("error", err),
+		)
+	}()
 
-	conn, err := dtls.DialWithContext(ctx, "udp", addr.addr, config)
+	req = c.withTraceparent(ctx, req)
+
+	buf := new(bytes.Buffer)
+
+	err = req.Marshall(buf)
 	if err != nil {
 		return
 	}
 
-	_, err = conn.Write(buf.Bytes())
+	conn, err := c.dial(ctx, addr)
 	if err != nil {
 		return
 	}
 
-	payload := make([]byte, 0)
-	for {
-		// Work in buffers of 256kb
-		data := make([]byte, 256000)
+	//#nosec: G307
+	defer conn.Close()
+
+	// Closing the connection is the only way of interrupting reads
+	// and writes which are already in progress
+	stop := context.AfterFunc(ctx, func() {
+		//#nosec: G104
+		conn.Close()
+	})
+	defer stop()
 
-		read, err := conn.Read(data)
+	if c.ReadTimeout > 0 {
+		err = conn.SetReadDeadline(time.Now().Add(c.ReadTimeout))
 		if err != nil {
-			return nil, err
+			return
 		}
+	}
 
-		payload = append(payload, data...)
-		if read < 256000 {
-			break
-		}
+	err = frame.Write(conn, buf.Bytes())
+	if err != nil {
+		return
+	}
+
+	payload, err := frame.Read(conn, c.MaxResponseSize)
+	if err != nil {
+		return
 	}
 
-	buf = bytes.NewBuffer(payload)
 	page = new(types.Page)
 
-	err = page.Unmarshall(buf)
+	err = page.Unmarshall(bytes.NewBuffer(payload))
 
 	return
 }
+
+// withTraceparent returns req with the traceparent for ctx set in its Args,
+// leaving req itself untouched
+func (c *Client) withTraceparent(ctx context.Context, req *types.Request) *types.Request {
+	if c.Traceparent == nil {
+		return req
+	}
+
+	if _, ok := req.Args[types.ArgTraceparent]; ok {
+		return req
+	}
+
+	traceparent := c.Traceparent(ctx)
+	if traceparent == "" {
+		return req
+	}
+
+	args := make(map[string]string, len(req.Args)+1)
+	for k, v := range req.Args {
+		args[k] = v
+	}
+
+	args[types.ArgTraceparent] = traceparent
+
+	r := *req
+	r.Args = args
+
+	return &r
+}
+
+func (c *Client) dial(ctx context.Context, addr Address) (conn *dtls.Conn, err error) {
+	config := c.config(addr)
+
+	if c.DialTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.DialTimeout)
+		defer cancel()
+	}
+
+	udpConn, err := net.DialUDP("udp", nil, addr.addr)
+	if err != nil {
+		return
+	}
+
+	// Responses spanning many records arrive in a burst; give the
+	// kernel room to hold onto them until we get round to reading
+	// them, rather than dropping them on the floor
+	//#nosec: G104
+	udpConn.SetReadBuffer(readBufferSize)
+
+	return dtls.ClientWithContext(ctx, udpConn, config)
+}
+
+// config returns the DTLS configuration used to connect to addr
+func (c *Client) config(addr Address) *dtls.Config {
+	if c.PSK != nil {
+		return &dtls.Config{
+			PSK:                  c.PSK,
+			PSKIdentityHint:      c.PSKIdentity,
+			CipherSuites:         psk.CipherSuites,
+			ExtendedMasterSecret: dtls.RequireExtendedMasterSecret,
+		}
+	}
+
+	config := &dtls.Config{
+		Certificates:          []tls.Certificate{c.Certificate},
+		RootCAs:               c.RootCAs,
+		ServerName:            addr.host,
+		InsecureSkipVerify:    c.InsecureSkipVerify,
+		VerifyPeerCertificate: c.VerifyPeerCertificate,
+		ExtendedMasterSecret:  dtls.RequireExtendedMasterSecret,
+	}
+
+	if c.KnownHosts != nil {
+		config.VerifyPeerCertificate = c.KnownHosts.verifier(addr.Host(), c.VerifyPeerCertificate)
+	}
+
+	return config
+}
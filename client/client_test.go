@@ -0,0 +1,223 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jspc/gordon"
+	"github.com/jspc/gordon/frame"
+	"github.com/jspc/gordon/types"
+	"github.com/pion/dtls/v2/pkg/crypto/selfsign"
+)
+
+func testPage(_ context.Context, req *types.Request) (*types.Page, error) {
+	return &types.Page{
+		Title: "A Test Page",
+		Sections: []types.Section{
+			{Title: "Chapter 1", Body: strings.Repeat("some text nobody will read\n", 1000)},
+		},
+		Status: types.StatusOK,
+	}, nil
+}
+
+func slowPage(ctx context.Context, req *types.Request) (*types.Page, error) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+	}
+
+	return testPage(ctx, req)
+}
+
+// startServer serves h on address until the test is done
+func startServer(t *testing.T, h gordon.HandlerFunc, address string) Address {
+	t.Helper()
+
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	l, err := gordon.NewContextListener(h, cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//#nosec: G104
+	go l.ListenAndServe(address)
+
+	t.Cleanup(func() {
+		//#nosec: G104
+		l.Close()
+	})
+
+	// Give the listener a moment to start
+	time.Sleep(time.Millisecond * 50)
+
+	addr, err := ParseAddress("//" + address + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return addr
+}
+
+func TestNewClient(t *testing.T) {
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Certificate.Certificate) == 0 {
+		t.Error("expected a certificate to be generated")
+	}
+}
+
+func TestClient_Do(t *testing.T) {
+	fast := startServer(t, testPage, "localhost:4460")
+	slow := startServer(t, slowPage, "localhost:4461")
+
+	isTooLarge := func(err error) bool { return errors.As(err, new(frame.TooLargeError)) }
+	isDeadline := func(err error) bool { return errors.Is(err, context.DeadlineExceeded) }
+	isAnyError := func(err error) bool { return err != nil }
+
+	for _, test := range []struct {
+		name        string
+		addr        Address
+		modify      func(*Client)
+		timeout     time.Duration
+		expectError func(error) bool
+	}{
+		{"Requests return pages", fast, func(*Client) {}, time.Second, nil},
+		{"Responses larger than MaxResponseSize fail", fast, func(c *Client) { c.MaxResponseSize = 1024 }, time.Second, isTooLarge},
+		{"Slow responses time out", slow, func(c *Client) { c.ReadTimeout = time.Millisecond * 50 }, time.Second, isAnyError},
+		{"Cancelled contexts abandon requests", slow, func(*Client) {}, time.Millisecond * 50, isDeadline},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := NewClient()
+			test.modify(c)
+
+			ctx, cancel := context.WithTimeout(context.Background(), test.timeout)
+			defer cancel()
+
+			page, err := c.Do(ctx, test.addr, &types.Request{Verb: types.VerbRead})
+			if test.expectError != nil {
+				if !test.expectError(err) {
+					t.Errorf("unexpected error %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if page.Title != "A Test Page" {
+				t.Errorf("unexpected page %#v", page)
+			}
+		})
+	}
+}
+
+func TestClient_Do_Reuse(t *testing.T) {
+	addr := startServer(t, testPage, "localhost:4463")
+
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error)
+	for i := 0; i < 10; i++ {
+		go func() {
+			page, err := c.Do(context.Background(), addr, &types.Request{Verb: types.VerbRead})
+			if err == nil && page.Title != "A Test Page" {
+				err = fmt.Errorf("unexpected page %#v", page)
+			}
+
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < 10; i++ {
+		err := <-errs
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestClient_withTraceparent(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	for _, test := range []struct {
+		name        string
+		traceparent func(context.Context) string
+		args        map[string]string
+		expect      string
+	}{
+		{"No hook", nil, nil, ""},
+		{"Hook returns nothing", func(context.Context) string { return "" }, nil, ""},
+		{"Hook sets traceparent", func(context.Context) string { return traceparent }, nil, traceparent},
+		{"Existing traceparent is kept", func(context.Context) string { return traceparent }, map[string]string{types.ArgTraceparent: "mine"}, "mine"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Client{Traceparent: test.traceparent}
+			req := &types.Request{Verb: types.VerbRead, Args: test.args}
+
+			received := c.withTraceparent(context.Background(), req).Args[types.ArgTraceparent]
+			if received != test.expect {
+				t.Errorf("expected %q, received %q", test.expect, received)
+			}
+
+			if test.args == nil && req.Args != nil {
+				t.Errorf("request was modified: %#v", req.Args)
+			}
+		})
+	}
+}
+
+func TestClient_GetIfModified(t *testing.T) {
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	addr := startServer(t, func(ctx context.Context, req *types.Request) (*types.Page, error) {
+		p, err := testPage(ctx, req)
+		p.Meta.Revision = "r1"
+		p.Meta.Published = published
+
+		return p, err
+	}, "localhost:4482")
+
+	c, _ := NewClient()
+
+	for _, test := range []struct {
+		name         string
+		meta         types.Metadata
+		expectStatus types.Status
+	}{
+		{"Unknown copies are sent in full", types.Metadata{}, types.StatusOK},
+		{"Stale copies are sent in full", types.Metadata{Revision: "r0"}, types.StatusOK},
+		{"Current copies are not modified", types.Metadata{Revision: "r1"}, types.StatusNotModified},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			page, err := c.GetIfModified(context.Background(), addr, test.meta)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if page.Status != test.expectStatus {
+				t.Errorf("expected %v, received %v", test.expectStatus, page.Status)
+			}
+
+			if page.Status == types.StatusNotModified && len(page.Sections) > 0 {
+				t.Error("expected not modified page to be empty")
+			}
+
+			if page.Meta.Revision != "r1" {
+				t.Errorf("expected revision %q, received %q", "r1", page.Meta.Revision)
+			}
+		})
+	}
+}
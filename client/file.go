@@ -0,0 +1,27 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// writeFile writes data to the file at path, readable only by the current
+// user, creating any missing directories along the way.
+//
+// data is written to a temporary file which is renamed over the top of
+// path, so that a crash halfway through can't leave a truncated file behind
+func writeFile(path string, data []byte) (err error) {
+	err = os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return
+	}
+
+	tmp := path + ".tmp"
+
+	err = os.WriteFile(tmp, data, 0o600)
+	if err != nil {
+		return
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,210 @@
+package client
+
+import (
+	"bufio"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jspc/gordon/internal/fingerprint"
+)
+
+// ErrNoCertificate is returned when verifying a server which presents no
+// certificate to pin
+var ErrNoCertificate = errors.New("server presented no certificate")
+
+// A CertificateMismatchError is returned when a server presents a certificate
+// which doesn't match the one pinned for it in KnownHosts.
+//
+// This either means the server has changed its certificate, or that
+// something is pretending to be the server
+type CertificateMismatchError struct {
+	Server   string
+	Expected string
+	Received string
+}
+
+// Error fulfills the error interface
+func (e CertificateMismatchError) Error() string {
+	return fmt.Sprintf("certificate for %s does not match known hosts: expected %s, received %s. "+
+		"If the server has legitimately changed its certificate, replace the pin with KnownHosts.Accept",
+		e.Server, e.Expected, e.Received,
+	)
+}
+
+// Fingerprint returns the fingerprint KnownHosts uses to identify the DER
+// encoded certificate cert
+func Fingerprint(cert []byte) string {
+	return fingerprint.Of(cert)
+}
+
+// KnownHosts is a trust-on-first-use store of server certificate
+// fingerprints, keyed by server host and port, and backed by a file.
+//
+// The first time a server is connected to, the fingerprint of its
+// certificate is pinned and written to the file; each time after that the
+// server must present the same certificate, or the connection is refused
+// with a CertificateMismatchError.
+//
+// The file contains one server per line, of the form
+//
+//	gordon.example.com:4444 sha256:5f2b...
+//
+// Blank lines, and lines starting with '#', are ignored.
+//
+// KnownHosts is safe to use from multiple goroutines, and should be created
+// with LoadKnownHosts
+type KnownHosts struct {
+	path  string
+	mu    sync.Mutex
+	hosts map[string]string
+}
+
+// DefaultKnownHostsPath returns the default location of the known hosts
+// file: ~/.gordon/known_hosts
+func DefaultKnownHostsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".gordon", "known_hosts"), nil
+}
+
+// LoadKnownHosts reads the known hosts file at path. Files which don't
+// exist yet are treated as empty, and created when the first pin is written
+func LoadKnownHosts(path string) (k *KnownHosts, err error) {
+	k = &KnownHosts{
+		path:  path,
+		hosts: make(map[string]string),
+	}
+
+	//#nosec: G304
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return k, nil
+	}
+
+	if err != nil {
+		return
+	}
+
+	//#nosec: G307
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], fingerprint.Prefix) {
+			return nil, fmt.Errorf("%s:%d: malformed known hosts entry", path, line)
+		}
+
+		k.hosts[fields[0]] = fields[1]
+	}
+
+	return k, scanner.Err()
+}
+
+// Fingerprint returns the fingerprint pinned for server, if there is one
+func (k *KnownHosts) Fingerprint(server string) (fingerprint string, ok bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	fingerprint, ok = k.hosts[server]
+
+	return
+}
+
+// Accept pins fingerprint for server, replacing any existing pin, and
+// writes the known hosts file
+func (k *KnownHosts) Accept(server, fingerprint string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.hosts[server] = fingerprint
+
+	return k.write()
+}
+
+// Remove deletes the pin for server, if there is one, and writes the known
+// hosts file. The next connection to server is then trusted on first use
+func (k *KnownHosts) Remove(server string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	delete(k.hosts, server)
+
+	return k.write()
+}
+
+// Verify checks the certificate presented by server against its pin,
+// pinning it if server has never been seen before
+func (k *KnownHosts) Verify(server string, rawCerts [][]byte) error {
+	if len(rawCerts) == 0 {
+		return ErrNoCertificate
+	}
+
+	received := Fingerprint(rawCerts[0])
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	expected, ok := k.hosts[server]
+	if !ok {
+		k.hosts[server] = received
+
+		return k.write()
+	}
+
+	if expected != received {
+		return CertificateMismatchError{
+			Server:   server,
+			Expected: expected,
+			Received: received,
+		}
+	}
+
+	return nil
+}
+
+// verifier returns a function suitable for dtls.Config.VerifyPeerCertificate
+// which verifies server, and then calls next, if set
+func (k *KnownHosts) verifier(server string, next func([][]byte, [][]*x509.Certificate) error) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		err := k.Verify(server, rawCerts)
+		if err != nil || next == nil {
+			return err
+		}
+
+		return next(rawCerts, verifiedChains)
+	}
+}
+
+// write replaces the known hosts file with the current set of pins,
+// and must be called with mu held
+func (k *KnownHosts) write() error {
+	servers := make([]string, 0, len(k.hosts))
+	for server := range k.hosts {
+		servers = append(servers, server)
+	}
+
+	slices.Sort(servers)
+
+	sb := new(strings.Builder)
+	for _, server := range servers {
+		fmt.Fprintf(sb, "%s %s\n", server, k.hosts[server])
+	}
+
+	return writeFile(k.path, []byte(sb.String()))
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jspc/gordon/types"
+)
+
+func TestLoadKnownHosts(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		contents    string
+		expectHosts map[string]string
+		expectError bool
+	}{
+		{"Empty files are empty", "", map[string]string{}, false},
+		{"Comments and blank lines are ignored", "# a comment\n\nexample.com:4444 sha256:abcd\n", map[string]string{"example.com:4444": "sha256:abcd"}, false},
+		{"Malformed lines error", "example.com:4444\n", nil, true},
+		{"Unknown fingerprints error", "example.com:4444 md5:abcd\n", nil, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "known_hosts")
+
+			err := os.WriteFile(path, []byte(test.contents), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			k, err := LoadKnownHosts(path)
+			if err != nil && !test.expectError {
+				t.Fatalf("unexpected error: %v", err)
+			} else if err == nil && test.expectError {
+				t.Fatal("expected error")
+			}
+
+			if test.expectError {
+				return
+			}
+
+			if len(k.hosts) != len(test.expectHosts) {
+				t.Errorf("expected %v, received %v", test.expectHosts, k.hosts)
+			}
+
+			for server, fingerprint := range test.expectHosts {
+				rcvd, _ := k.Fingerprint(server)
+				if rcvd != fingerprint {
+					t.Errorf("%s: expected %q, received %q", server, fingerprint, rcvd)
+				}
+			}
+		})
+	}
+}
+
+func TestKnownHosts_Verify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gordon", "known_hosts")
+
+	k, err := LoadKnownHosts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original := [][]byte{[]byte("a certificate")}
+	changed := [][]byte{[]byte("another certificate")}
+
+	t.Run("Unknown servers are trusted on first use", func(t *testing.T) {
+		err := k.Verify("example.com:4444", original)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Pins are persisted", func(t *testing.T) {
+		reloaded, err := LoadKnownHosts(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fingerprint, ok := reloaded.Fingerprint("example.com:4444")
+		if !ok || fingerprint != Fingerprint(original[0]) {
+			t.Errorf("expected %q, received %q", Fingerprint(original[0]), fingerprint)
+		}
+	})
+
+	t.Run("Matching certificates are trusted", func(t *testing.T) {
+		err := k.Verify("example.com:4444", original)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Changed certificates are refused", func(t *testing.T) {
+		err := k.Verify("example.com:4444", changed)
+
+		var mismatch CertificateMismatchError
+		if !errors.As(err, &mismatch) {
+			t.Fatalf("expected CertificateMismatchError, received %v", err)
+		}
+
+		if mismatch.Received != Fingerprint(changed[0]) {
+			t.Errorf("expected %q, received %q", Fingerprint(changed[0]), mismatch.Received)
+		}
+	})
+
+	t.Run("Accepted certificates replace pins", func(t *testing.T) {
+		err := k.Accept("example.com:4444", Fingerprint(changed[0]))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = k.Verify("example.com:4444", changed)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Servers presenting no certificate are refused", func(t *testing.T) {
+		err := k.Verify("example.com:4444", nil)
+		if !errors.Is(err, ErrNoCertificate) {
+			t.Errorf("expected ErrNoCertificate, received %v", err)
+		}
+	})
+}
+
+func TestClient_Do_KnownHosts(t *testing.T) {
+	addr := startServer(t, testPage, "localhost:4462")
+
+	k, err := LoadKnownHosts(filepath.Join(t.TempDir(), "known_hosts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, _ := NewClient()
+	c.KnownHosts = k
+
+	_, err = c.Do(context.Background(), addr, &types.Request{Verb: types.VerbRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := k.Fingerprint(addr.Host()); !ok {
+		t.Fatal("expected server to be pinned")
+	}
+
+	err = k.Accept(addr.Host(), Fingerprint([]byte("some other certificate")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Do(context.Background(), addr, &types.Request{Verb: types.VerbRead})
+	if !errors.As(err, new(CertificateMismatchError)) {
+		t.Errorf("expected CertificateMismatchError, received %v", err)
+	}
+
+	// A client rejecting the server's certificate mustn't stop the
+	// server from serving anybody else
+	err = k.Remove(addr.Host())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Do(context.Background(), addr, &types.Request{Verb: types.VerbRead})
+	if err != nil {
+		t.Errorf("unexpected error after rejected handshake: %v", err)
+	}
+}
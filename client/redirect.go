@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jspc/gordon/types"
+)
+
+// ErrTooManyRedirects is returned when following redirects, where a server
+// redirects a request more than MaxRedirects times
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// A RedirectLoopError is returned when following redirects, where a server
+// redirects a request back to a document it has already been redirected
+// from
+type RedirectLoopError struct {
+	// Address is the address of the document redirected to a second time
+	Address string
+}
+
+// Error fulfills the error interface
+func (e RedirectLoopError) Error() string {
+	return fmt.Sprintf("redirect loop at %s", e.Address)
+}
+
+// follow follows the redirect page from the document req asked for at addr,
+// and any redirects after that, up to MaxRedirects
+func (c *Client) follow(ctx context.Context, addr Address, req *types.Request, page *types.Page) (*types.Page, error) {
+	seen := map[string]bool{
+		redirectKey(addr, req.ID): true,
+	}
+
+	var err error
+	for redirects := 0; page.Status == types.StatusRedirect; redirects++ {
+		if redirects == c.MaxRedirects {
+			return nil, ErrTooManyRedirects
+		}
+
+		addr, err = addr.resolve(page.Location)
+		if err != nil {
+			return nil, err
+		}
+
+		key := redirectKey(addr, page.Location.Page)
+		if seen[key] {
+			return nil, RedirectLoopError{Address: key}
+		}
+
+		seen[key] = true
+
+		r := *req
+		r.ID = page.Location.Page
+		req = &r
+
+		page, err = c.do(ctx, addr, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// redirectKey identifies the document id on the server at addr
+func redirectKey(addr Address, id uuid.UUID) string {
+	return "//" + addr.Server() + "/" + id.String()
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jspc/gordon"
+	"github.com/jspc/gordon/types"
+)
+
+// redirectServer redirects requests for each key of redirects to the
+// corresponding value, and serves testPage for anything else
+func redirectServer(redirects map[uuid.UUID]types.PageRef) gordon.HandlerFunc {
+	return func(ctx context.Context, req *types.Request) (*types.Page, error) {
+		if to, ok := redirects[req.ID]; ok {
+			return gordon.Redirect(req, to), nil
+		}
+
+		return testPage(ctx, req)
+	}
+}
+
+func TestClient_Do_Redirects(t *testing.T) {
+	var (
+		moved     = uuid.Must(uuid.NewV4())
+		movedAway = uuid.Must(uuid.NewV4())
+		loopA     = uuid.Must(uuid.NewV4())
+		loopB     = uuid.Must(uuid.NewV4())
+		chain     = uuid.Must(uuid.NewV4())
+		target    = uuid.Must(uuid.NewV4())
+	)
+
+	redirects := map[uuid.UUID]types.PageRef{
+		moved:     {Page: target},
+		movedAway: {Page: target, Server: "localhost:4481"},
+		loopA:     {Page: loopB},
+		loopB:     {Page: loopA},
+	}
+
+	// chain redirects through three more documents before reaching target
+	next := chain
+	for i := 0; i < 3; i++ {
+		id := uuid.Must(uuid.NewV4())
+		redirects[next] = types.PageRef{Page: id}
+		next = id
+	}
+
+	redirects[next] = types.PageRef{Page: target}
+
+	addr := startServer(t, redirectServer(redirects), "localhost:4480")
+	startServer(t, redirectServer(nil), "localhost:4481")
+
+	for _, test := range []struct {
+		name         string
+		maxRedirects int
+		id           uuid.UUID
+		expectStatus types.Status
+		expectErr    error
+	}{
+		{"Redirects aren't followed by default", 0, moved, types.StatusRedirect, nil},
+		{"Redirects are followed", 5, moved, types.StatusOK, nil},
+		{"Redirects to other servers are followed", 5, movedAway, types.StatusOK, nil},
+		{"Chains of redirects are followed", 5, chain, types.StatusOK, nil},
+		{"Chains of redirects are limited", 3, chain, 0, ErrTooManyRedirects},
+		{"Loops are detected", 5, loopA, 0, RedirectLoopError{}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := NewClient()
+			c.MaxRedirects = test.maxRedirects
+
+			page, err := c.Do(context.Background(), addr, &types.Request{Verb: types.VerbRead, ID: test.id})
+
+			switch expect := test.expectErr.(type) {
+			case nil:
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+			case RedirectLoopError:
+				if !errors.As(err, &expect) {
+					t.Fatalf("expected RedirectLoopError, received %v", err)
+				}
+
+			default:
+				if !errors.Is(err, expect) {
+					t.Fatalf("expected %v, received %v", expect, err)
+				}
+			}
+
+			if err != nil {
+				return
+			}
+
+			if page.Status != test.expectStatus {
+				t.Errorf("expected status %v, received %v", test.expectStatus, page.Status)
+			}
+
+			if page.Status == types.StatusRedirect && page.Location.Page != target {
+				t.Errorf("expected redirect to %s, received %s", target, page.Location.Page)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jspc/gordon/types"
+)
+
+// The errors a StatusError matches with errors.Is, by status, so that
+// callers can tell one sort of error page from another without inspecting
+// the page itself
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrForbidden   = errors.New("forbidden")
+	ErrBadRequest  = errors.New("bad request")
+	ErrRedirect    = errors.New("redirected")
+	ErrNotModified = errors.New("not modified")
+	ErrTooLarge    = errors.New("request too large")
+	ErrUnavailable = errors.New("unavailable")
+	ErrServer      = errors.New("server error")
+)
+
+// A StatusError is returned by CheckStatus for pages with any status other
+// than types.StatusOK, and carries the page the server sent.
+//
+//	page, err := c.Get(ctx, addr)
+//	if err == nil {
+//		err = client.CheckStatus(page)
+//	}
+//
+//	if errors.Is(err, client.ErrNotFound) {
+//		// ...
+//	}
+type StatusError struct {
+	Page *types.Page
+}
+
+// Error fulfills the error interface
+func (e StatusError) Error() string {
+	if e.Page.Title == "" {
+		return e.Unwrap().Error()
+	}
+
+	return fmt.Sprintf("%s: %s", e.Unwrap(), e.Page.Title)
+}
+
+// Unwrap returns the error matching the status of the page, so that
+// StatusErrors may be checked with errors.Is
+func (e StatusError) Unwrap() error {
+	switch e.Page.Status {
+	case types.StatusNotFound:
+		return ErrNotFound
+	case types.StatusForbidden:
+		return ErrForbidden
+	case types.StatusBadRequest:
+		return ErrBadRequest
+	case types.StatusRedirect:
+		return ErrRedirect
+	case types.StatusNotModified:
+		return ErrNotModified
+	case types.StatusTooLarge:
+		return ErrTooLarge
+	case types.StatusUnavailable:
+		return ErrUnavailable
+	}
+
+	return ErrServer
+}
+
+// CheckStatus returns a StatusError where page has any status other than
+// types.StatusOK, and nil otherwise
+func CheckStatus(page *types.Page) error {
+	if page.Status == types.StatusOK {
+		return nil
+	}
+
+	return StatusError{Page: page}
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jspc/gordon/types"
+)
+
+func TestCheckStatus(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		page        *types.Page
+		expectErr   error
+		expectError string
+	}{
+		{"OK pages are fine", &types.Page{Status: types.StatusOK}, nil, ""},
+		{"Not found", &types.Page{Status: types.StatusNotFound, Title: "Page Not Found"}, ErrNotFound, "not found: Page Not Found"},
+		{"Forbidden", &types.Page{Status: types.StatusForbidden}, ErrForbidden, "forbidden"},
+		{"Bad request", &types.Page{Status: types.StatusBadRequest}, ErrBadRequest, "bad request"},
+		{"Redirect", &types.Page{Status: types.StatusRedirect}, ErrRedirect, "redirected"},
+		{"Not modified", &types.Page{Status: types.StatusNotModified}, ErrNotModified, "not modified"},
+		{"Too large", &types.Page{Status: types.StatusTooLarge}, ErrTooLarge, "request too large"},
+		{"Unavailable", &types.Page{Status: types.StatusUnavailable}, ErrUnavailable, "unavailable"},
+		{"Generic errors", &types.Page{Status: types.StatusError, Title: "Internal Error"}, ErrServer, "server error: Internal Error"},
+		{"Unknown statuses are server errors", &types.Page{Status: types.StatusUnknown}, ErrServer, "server error"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckStatus(test.page)
+			if test.expectErr == nil {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, test.expectErr) {
+				t.Errorf("expected %v, received %v", test.expectErr, err)
+			}
+
+			var se StatusError
+			if !errors.As(err, &se) || se.Page != test.page {
+				t.Errorf("expected StatusError carrying the page, received %#v", err)
+			}
+
+			if err.Error() != test.expectError {
+				t.Errorf("expected %q, received %q", test.expectError, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package gordon
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+)
+
+type contextKey int
+
+const (
+	peerKey contextKey = iota
+)
+
+// A Peer describes the client which made a request
+type Peer struct {
+	// RemoteAddr is the address the request came from
+	RemoteAddr net.Addr
+
+	// Certificate is the certificate the client presented during the
+	// DTLS handshake, if it presented one
+	Certificate *x509.Certificate
+
+	// Fingerprint is the fingerprint of Certificate, as returned by
+	// client.Fingerprint, or empty where there is no Certificate
+	Fingerprint string
+
+	// Subject is the distinguished name from Certificate, or empty where
+	// there is no Certificate
+	Subject string
+
+	// Verified is true where Certificate was verified against the
+	// Listener's ClientCAs; unverified certificates say nothing about
+	// who the client is, only that it's the same client each time
+	Verified bool
+
+	// PSKIdentity is the identity a client authenticated with, where the
+	// Listener uses pre-shared keys rather than certificates. Clients only
+	// get this far where they know the key PSK.Key returned for this
+	// identity, and so it can be trusted in the same way as a Verified
+	// Certificate
+	PSKIdentity string
+}
+
+// PeerFromContext returns the Peer which made the request being served
+// with ctx, and false where ctx didn't come from a Listener
+func PeerFromContext(ctx context.Context) (p Peer, ok bool) {
+	p, ok = ctx.Value(peerKey).(Peer)
+
+	return
+}
+
+// RemoteAddr returns the address of the client which made the request
+// being served with ctx, or nil where ctx didn't come from a Listener
+func RemoteAddr(ctx context.Context) net.Addr {
+	p, _ := PeerFromContext(ctx)
+
+	return p.RemoteAddr
+}
+
+func withPeer(ctx context.Context, p Peer) context.Context {
+	return context.WithValue(ctx, peerKey, p)
+}
@@ -0,0 +1,208 @@
+package gordon
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/jspc/gordon/client"
+	"github.com/jspc/gordon/types"
+	"github.com/pion/dtls/v2"
+	"github.com/pion/dtls/v2/pkg/crypto/selfsign"
+)
+
+// newCA returns a certificate authority, and a function which issues
+// client certificates signed by it
+func newCA(t *testing.T) (*x509.CertPool, func(cn string) tls.Certificate) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	return pool, func(cn string) tls.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      pkix.Name{CommonName: cn},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return tls.Certificate{
+			Certificate: [][]byte{der},
+			PrivateKey:  key,
+		}
+	}
+}
+
+// peerHandler answers every request with a page describing the Peer
+// which made it
+func peerHandler(ctx context.Context, _ *types.Request) (*types.Page, error) {
+	p, ok := PeerFromContext(ctx)
+	if !ok {
+		return errorPage([16]byte{}, types.StatusError, "No Peer"), nil
+	}
+
+	verified := "unverified"
+	if p.Verified {
+		verified = "verified"
+	}
+
+	return &types.Page{
+		Title:  "Peer",
+		Status: types.StatusOK,
+		Labels: map[string]string{
+			"subject":     p.Subject,
+			"fingerprint": p.Fingerprint,
+			"verified":    verified,
+			"psk":         p.PSKIdentity,
+		},
+	}, nil
+}
+
+func TestListener_ListenAndServe_ClientAuth(t *testing.T) {
+	serverCert, _ := selfsign.GenerateSelfSigned()
+	selfSigned, _ := selfsign.GenerateSelfSigned()
+
+	pool, issue := newCA(t)
+	issued := issue("A. N. Tester")
+
+	for _, test := range []struct {
+		name              string
+		address           string
+		clientAuth        dtls.ClientAuthType
+		clientCert        tls.Certificate
+		expectError       bool
+		expectSubject     string
+		expectVerified    string
+		expectFingerprint string
+	}{
+		{"Certificates are not asked for by default", "localhost:4470", dtls.NoClientCert, issued, false, "", "unverified", ""},
+		{"Requested certificates are passed through unverified", "localhost:4471", dtls.RequestClientCert, issued, false, "CN=A. N. Tester", "unverified", client.Fingerprint(issued.Certificate[0])},
+		{"Verified certificates are passed through", "localhost:4472", dtls.RequireAndVerifyClientCert, issued, false, "CN=A. N. Tester", "verified", client.Fingerprint(issued.Certificate[0])},
+		{"Unverifiable certificates are refused", "localhost:4473", dtls.RequireAndVerifyClientCert, selfSigned, true, "", "", ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			l, _ := NewContextListener(HandlerFunc(peerHandler), serverCert)
+			l.ClientAuth = test.clientAuth
+			l.ClientCAs = pool
+
+			defer l.Close()
+
+			startListener(t, &l, test.address)
+
+			addr, _ := client.ParseAddress("//" + test.address + "/")
+
+			c, _ := client.NewClient()
+			c.Certificate = test.clientCert
+			c.DialTimeout = time.Millisecond * 500
+
+			page, err := c.Do(context.Background(), addr, &types.Request{Verb: types.VerbRead})
+			if err != nil && !test.expectError {
+				t.Fatalf("unexpected error: %v", err)
+			} else if err == nil && test.expectError {
+				t.Fatal("expected error")
+			}
+
+			if test.expectError {
+				return
+			}
+
+			for k, v := range map[string]string{
+				"subject":     test.expectSubject,
+				"verified":    test.expectVerified,
+				"fingerprint": test.expectFingerprint,
+			} {
+				if page.Labels[k] != v {
+					t.Errorf("%s: expected %q, received %q", k, v, page.Labels[k])
+				}
+			}
+		})
+	}
+}
+
+func TestListener_ListenAndServe_ClientAuthSurvivesRefusedClients(t *testing.T) {
+	serverCert, _ := selfsign.GenerateSelfSigned()
+	selfSigned, _ := selfsign.GenerateSelfSigned()
+
+	pool, issue := newCA(t)
+
+	l, _ := NewContextListener(HandlerFunc(peerHandler), serverCert)
+	l.ClientAuth = dtls.RequireAndVerifyClientCert
+	l.ClientCAs = pool
+
+	defer l.Close()
+
+	startListener(t, &l, "localhost:4474")
+
+	addr, _ := client.ParseAddress("//localhost:4474/")
+
+	for _, test := range []struct {
+		name        string
+		cert        tls.Certificate
+		expectError bool
+	}{
+		{"Clients without verifiable certificates are refused", selfSigned, true},
+		{"Clients with verifiable certificates are still served", issue("A. N. Tester"), false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := client.NewClient()
+			c.Certificate = test.cert
+			c.DialTimeout = time.Millisecond * 500
+
+			_, err := c.Get(context.Background(), addr)
+			if err != nil && !test.expectError {
+				t.Errorf("unexpected error: %v", err)
+			} else if err == nil && test.expectError {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestRemoteAddr_NoPeer(t *testing.T) {
+	if RemoteAddr(context.Background()) != nil {
+		t.Error("expected nil address")
+	}
+}
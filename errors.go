@@ -1,5 +1,29 @@
 package gordon
 
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jspc/gordon/types"
+)
+
+// ErrListenerClosed is returned by ListenAndServe once a Listener has been
+// closed, either via Close or Shutdown
+var ErrListenerClosed = errors.New("listener closed")
+
+// ErrRateLimited is logged when a connection is dropped because the address
+// it came from has exceeded the Listener's RateLimit
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// ErrTooManyConnections is logged when a connection is dropped because the
+// address it came from already has MaxConnectionsPerAddress connections in
+// flight
+var ErrTooManyConnections = errors.New("too many connections from address")
+
+// ErrMetricsNotServable is returned by ListenAndServe when MetricsAddress
+// is set, but the Listener's Metrics can't be served over HTTP
+var ErrMetricsNotServable = errors.New("metrics do not implement http.Handler")
+
 // A NilPageError is created when a Handler returns a nil Page, without
 // also returning a valid error
 type NilPageError struct{}
@@ -8,3 +32,94 @@ type NilPageError struct{}
 func (NilPageError) Error() string {
 	return "nil page returned from Handler"
 }
+
+// A PanicError is returned by the Recover Middleware when the Handler
+// it wraps panics
+type PanicError struct {
+	// Value is the value the Handler panicked with
+	Value any
+
+	// Stack is the stack trace of the goroutine which panicked
+	Stack []byte
+}
+
+// Error fulfills the error interface
+func (e PanicError) Error() string {
+	return fmt.Sprintf("panic in Handler: %v", e.Value)
+}
+
+// An ErrorPage is an error which knows how it should be shown to clients.
+//
+// Where a Handler returns an ErrorPage, the client is sent the Page it
+// renders. Any other error is sent as a generic error page, so that the
+// details of what went wrong aren't leaked to clients
+type ErrorPage interface {
+	error
+
+	// Page returns the page to send in answer to req
+	Page(req *types.Request) *types.Page
+}
+
+// A NotFoundError is returned by Handlers when the document a request is
+// for doesn't exist
+type NotFoundError struct{}
+
+// Error fulfills the error interface
+func (NotFoundError) Error() string {
+	return "not found"
+}
+
+// Page fulfills the ErrorPage interface
+func (NotFoundError) Page(req *types.Request) *types.Page {
+	return errorPage(req.ID, types.StatusNotFound, "Not Found")
+}
+
+// A ForbiddenError is returned by Handlers when the client making a
+// request isn't allowed to make it
+type ForbiddenError struct {
+	// Reason, where set, is sent to the client to explain why
+	Reason string
+}
+
+// Error fulfills the error interface
+func (e ForbiddenError) Error() string {
+	return reasonError("forbidden", e.Reason)
+}
+
+// Page fulfills the ErrorPage interface
+func (e ForbiddenError) Page(req *types.Request) *types.Page {
+	return reasonPage(req, types.StatusForbidden, "Forbidden", e.Reason)
+}
+
+// A BadRequestError is returned by Handlers when a request doesn't make
+// sense, such as where it is missing arguments
+type BadRequestError struct {
+	// Reason, where set, is sent to the client to explain what is wrong
+	// with the request
+	Reason string
+}
+
+// Error fulfills the error interface
+func (e BadRequestError) Error() string {
+	return reasonError("bad request", e.Reason)
+}
+
+// Page fulfills the ErrorPage interface
+func (e BadRequestError) Page(req *types.Request) *types.Page {
+	return reasonPage(req, types.StatusBadRequest, "Bad Request", e.Reason)
+}
+
+func reasonError(msg, reason string) string {
+	if reason == "" {
+		return msg
+	}
+
+	return msg + ": " + reason
+}
+
+func reasonPage(req *types.Request, status types.Status, title, reason string) *types.Page {
+	p := errorPage(req.ID, status, title)
+	p.Preamble = reason
+
+	return p
+}
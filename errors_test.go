@@ -0,0 +1,85 @@
+package gordon
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jspc/gordon/types"
+)
+
+func TestErrorPage(t *testing.T) {
+	id := uuid.Must(uuid.NewV4())
+	req := &types.Request{Verb: types.VerbRead, ID: id}
+
+	for _, test := range []struct {
+		name           string
+		err            ErrorPage
+		expectError    string
+		expectStatus   types.Status
+		expectTitle    string
+		expectPreamble string
+	}{
+		{"Not found", NotFoundError{}, "not found", types.StatusNotFound, "Not Found", ""},
+		{"Forbidden", ForbiddenError{}, "forbidden", types.StatusForbidden, "Forbidden", ""},
+		{"Forbidden, with a reason", ForbiddenError{Reason: "read only"}, "forbidden: read only", types.StatusForbidden, "Forbidden", "read only"},
+		{"Bad request, with a reason", BadRequestError{Reason: "missing Body"}, "bad request: missing Body", types.StatusBadRequest, "Bad Request", "missing Body"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if test.err.Error() != test.expectError {
+				t.Errorf("expected error %q, received %q", test.expectError, test.err.Error())
+			}
+
+			p := test.err.Page(req)
+
+			if p.Status != test.expectStatus {
+				t.Errorf("expected status %v, received %v", test.expectStatus, p.Status)
+			}
+
+			if p.Title != test.expectTitle {
+				t.Errorf("expected title %q, received %q", test.expectTitle, p.Title)
+			}
+
+			if p.Preamble != test.expectPreamble {
+				t.Errorf("expected preamble %q, received %q", test.expectPreamble, p.Preamble)
+			}
+
+			if p.Meta.ID != id {
+				t.Errorf("expected page for %s, received %s", id, p.Meta.ID)
+			}
+		})
+	}
+}
+
+func TestListener_handlerErr(t *testing.T) {
+	l, _ := NewListener(new(dummyHandler), tls.Certificate{})
+	l.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	conn, _ := net.Pipe()
+	defer conn.Close()
+
+	req := &types.Request{Verb: types.VerbRead}
+
+	for _, test := range []struct {
+		name        string
+		err         error
+		expectTitle string
+	}{
+		{"ErrorPages are rendered", NotFoundError{}, "Not Found"},
+		{"Wrapped ErrorPages are rendered", fmt.Errorf("looking up document: %w", NotFoundError{}), "Not Found"},
+		{"Other errors are hidden", errors.New("database on fire"), "Internal Error"},
+		{"Panics are hidden", PanicError{Value: "oh no"}, "Internal Error"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p := l.handlerErr(conn, req, test.err)
+			if p.Title != test.expectTitle {
+				t.Errorf("expected title %q, received %q", test.expectTitle, p.Title)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMalformedRecord is returned by Read when a record is too short to
+// contain a header, or when its header doesn't agree with the records
+// which came before it
+var ErrMalformedRecord = errors.New("malformed record")
+
+// A TooLargeError is returned when a message is larger than the receiver,
+// or the framing itself, allows
+type TooLargeError struct {
+	Size uint64
+	Max  uint64
+}
+
+// Error fulfills the error interface
+func (e TooLargeError) Error() string {
+	return fmt.Sprintf("message of %d bytes exceeds maximum size of %d bytes", e.Size, e.Max)
+}
@@ -0,0 +1,144 @@
+// Package frame implements the framing gordon uses to send messages over
+// DTLS which are larger than will fit in a single record.
+//
+// A message is split into records of at most RecordSize bytes of data,
+// each prefixed with a HeaderSize byte header made up of:
+//
+//  1. The length of the whole message, as a big-endian uint32
+//  2. The offset of this record's data within the message, as a big-endian uint32
+//
+// Every record carries the length of the whole message so that a receiver
+// may reject messages which are too large as soon as the first record
+// arrives, and every record carries its own offset so that records may be
+// reassembled in whichever order they arrive. A message is complete once
+// every byte of it has been received; records which overlap data already
+// received are rejected, rather than risk a message being reported complete
+// with gaps in it.
+//
+// Empty messages are sent as a single record containing only a header.
+package frame
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+const (
+	// RecordSize is the largest amount of message data Write puts in
+	// a single record, chosen to keep records comfortably inside
+	// a typical path MTU once DTLS has had its way with them
+	RecordSize = 1024
+
+	// HeaderSize is the size of the header at the start of every record
+	HeaderSize = 8
+
+	// maxRecordSize is the largest record we're prepared to read; larger
+	// than RecordSize so that we can read records from senders which
+	// chunk messages differently, and the same as the largest datagram
+	// pion/dtls will read from the network
+	maxRecordSize = 8192
+)
+
+// Write splits msg into records, writing each to w in a separate call
+// to Write, as a DTLS connection expects
+func Write(w io.Writer, msg []byte) (err error) {
+	if uint64(len(msg)) > math.MaxUint32 {
+		return TooLargeError{Size: uint64(len(msg)), Max: math.MaxUint32}
+	}
+
+	record := make([]byte, HeaderSize+RecordSize)
+	binary.BigEndian.PutUint32(record, uint32(len(msg)))
+
+	var offset int
+	for {
+		n := copy(record[HeaderSize:], msg[offset:])
+
+		//#nosec: G115
+		binary.BigEndian.PutUint32(record[4:], uint32(offset))
+
+		_, err = w.Write(record[:HeaderSize+n])
+		if err != nil {
+			return
+		}
+
+		offset += n
+		if offset >= len(msg) {
+			return
+		}
+	}
+}
+
+// Read reads records from r, reassembling and returning the message they
+// contain. Each call to r.Read must return exactly one record, as a DTLS
+// connection does.
+//
+// Messages larger than max bytes are rejected with a TooLargeError as soon as
+// the first record is read, without reading the rest; a max of zero or less
+// places no limit on message size. Since the whole message is allocated as
+// soon as the first record arrives, a max of zero or less allows a single
+// record to force up to 4GiB to be allocated, and so should only ever be used
+// with trusted senders.
+//
+// Records which overlap data already received, or which disagree with earlier
+// records about the size of the message, are rejected with ErrMalformedRecord.
+//
+// Read blocks until the whole message is received, or r returns an error,
+// and so callers should ensure r has a deadline set
+func Read(r io.Reader, max int) (msg []byte, err error) {
+	record := make([]byte, maxRecordSize)
+
+	var (
+		started  bool
+		received int
+
+		// covered holds a bit for every byte of msg, set once that
+		// byte has been received
+		covered []uint64
+	)
+
+	for {
+		n, err := r.Read(record)
+		if err != nil {
+			return nil, err
+		}
+
+		if n < HeaderSize {
+			return nil, ErrMalformedRecord
+		}
+
+		size := binary.BigEndian.Uint32(record)
+		offset := binary.BigEndian.Uint32(record[4:])
+		data := record[HeaderSize:n]
+
+		if !started {
+			if max > 0 && uint64(size) > uint64(max) {
+				return nil, TooLargeError{Size: uint64(size), Max: uint64(max)}
+			}
+
+			msg = make([]byte, size)
+			covered = make([]uint64, (uint64(size)+63)/64)
+			started = true
+		}
+
+		if uint64(size) != uint64(len(msg)) ||
+			uint64(offset)+uint64(len(data)) > uint64(len(msg)) {
+			return nil, ErrMalformedRecord
+		}
+
+		for i := uint64(offset); i < uint64(offset)+uint64(len(data)); i++ {
+			if covered[i/64]&(1<<(i%64)) != 0 {
+				return nil, ErrMalformedRecord
+			}
+
+			covered[i/64] |= 1 << (i % 64)
+		}
+
+		copy(msg[offset:], data)
+
+		received += len(data)
+		if received >= len(msg) {
+			return msg, nil
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package frame
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// records behaves like a DTLS connection, in that every Write is read
+// back by exactly one Read
+type records struct {
+	r [][]byte
+}
+
+func (rs *records) Write(b []byte) (int, error) {
+	rs.r = append(rs.r, bytes.Clone(b))
+
+	return len(b), nil
+}
+
+func (rs *records) Read(b []byte) (int, error) {
+	if len(rs.r) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(b, rs.r[0])
+	rs.r = rs.r[1:]
+
+	return n, nil
+}
+
+func TestWriteRead(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		size          int
+		expectRecords int
+	}{
+		{"Empty messages", 0, 1},
+		{"Small messages", 10, 1},
+		{"Messages filling exactly one record", RecordSize, 1},
+		{"Messages filling exactly two records", RecordSize * 2, 2},
+		{"Large messages", RecordSize*100 + 7, 101},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			msg := make([]byte, test.size)
+			for i := range msg {
+				msg[i] = byte(i)
+			}
+
+			rs := new(records)
+
+			err := Write(rs, msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(rs.r) != test.expectRecords {
+				t.Errorf("expected %d records, received %d", test.expectRecords, len(rs.r))
+			}
+
+			rcvd, err := Read(rs, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(msg, rcvd) {
+				t.Errorf("message was corrupted")
+			}
+		})
+	}
+}
+
+func TestRead_OutOfOrder(t *testing.T) {
+	msg := bytes.Repeat([]byte("abcdefg"), RecordSize)
+
+	rs := new(records)
+
+	err := Write(rs, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, j := 0, len(rs.r)-1; i < j; i, j = i+1, j-1 {
+		rs.r[i], rs.r[j] = rs.r[j], rs.r[i]
+	}
+
+	rcvd, err := Read(rs, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(msg, rcvd) {
+		t.Errorf("message was corrupted")
+	}
+}
+
+func TestRead_Errors(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		records [][]byte
+		max     int
+		expect  error
+	}{
+		{"Short records", [][]byte{{0, 0, 0}}, 0, ErrMalformedRecord},
+		{"Messages larger than max", [][]byte{{0, 0, 1, 0, 0, 0, 0, 0}}, 10, TooLargeError{Size: 256, Max: 10}},
+		{"Records past the end of the message", [][]byte{{0, 0, 0, 2, 0, 0, 0, 1, 'a', 'b'}}, 0, ErrMalformedRecord},
+		{"Records disagreeing on size", [][]byte{{0, 0, 0, 2, 0, 0, 0, 0, 'a'}, {0, 0, 0, 3, 0, 0, 0, 1, 'b'}}, 0, ErrMalformedRecord},
+		{"Repeated records", [][]byte{{0, 0, 0, 2, 0, 0, 0, 0, 'a'}, {0, 0, 0, 2, 0, 0, 0, 0, 'a'}}, 0, ErrMalformedRecord},
+		{"Overlapping records", [][]byte{{0, 0, 0, 3, 0, 0, 0, 0, 'a', 'b'}, {0, 0, 0, 3, 0, 0, 0, 1, 'b', 'c'}}, 0, ErrMalformedRecord},
+		{"Truncated messages", [][]byte{{0, 0, 0, 2, 0, 0, 0, 0, 'a'}}, 0, io.EOF},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Read(&records{r: test.records}, test.max)
+			if !errors.Is(err, test.expect) {
+				t.Errorf("expected %v, received %v", test.expect, err)
+			}
+		})
+	}
+}
@@ -4,18 +4,16 @@ go 1.22.3
 
 require (
 	github.com/gofrs/uuid/v5 v5.2.0
-	github.com/kr/pretty v0.3.1
 	github.com/pion/dtls/v2 v2.2.11
+	github.com/pion/transport/v2 v2.2.4
 	github.com/vinyl-linux/mint v0.4.2
 	go.uber.org/zap v1.27.0
 	golang.org/x/sync v0.7.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/kr/text v0.2.0 // indirect
 	github.com/pion/logging v0.2.2 // indirect
-	github.com/pion/transport/v2 v2.2.4 // indirect
-	github.com/rogpeppe/go-internal v1.9.0 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
 	golang.org/x/crypto v0.18.0 // indirect
 	golang.org/x/net v0.20.0 // indirect
@@ -0,0 +1,20 @@
+// Package fingerprint implements the certificate fingerprints shared by
+// gordon servers, which expose the fingerprints of client certificates to
+// Handlers, and clients, which pin the fingerprints of server certificates
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Prefix identifies the hash used to produce a fingerprint, and starts
+// every fingerprint
+const Prefix = "sha256:"
+
+// Of returns the fingerprint of the DER encoded certificate cert
+func Of(cert []byte) string {
+	sum := sha256.Sum256(cert)
+
+	return Prefix + hex.EncodeToString(sum[:])
+}
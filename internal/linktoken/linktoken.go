@@ -0,0 +1,212 @@
+// Package linktoken finds the index links, such as [l:0], which section
+// bodies use to refer to elements of a page's Links. It is shared by page
+// validation, in types, and the markup package, which can't share code any
+// other way without an import cycle.
+//
+// Index links within code, and escaped index links, are text, and so this
+// package knows just enough of the markup described by the markup package
+// to tell where code and escapes are
+package linktoken
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Fence is the shortest line which opens and closes a code block
+const Fence = "```"
+
+// Escapable are the characters which, following a backslash, are text
+const Escapable = "\\*`[#>"
+
+var pattern = regexp.MustCompile(`\[l:(\d+)\]`)
+
+// A Token is a single index link within a string
+type Token struct {
+	// Start and End are the byte offsets of the token, such that
+	// s[Start:End] is the token as written
+	Start, End int
+
+	// Index is the element of Links the token refers to
+	Index int
+}
+
+// A Span is a run of text within a line between a pair of delimiters, either
+// * for emphasis, or ` for code
+type Span struct {
+	Delim byte
+
+	// Start and End are the byte offsets of the opening and closing
+	// delimiters
+	Start, End int
+}
+
+// Find returns every index link in body, in order, other than those in code
+// blocks and code spans, and those which are escaped, which are text
+func Find(body string) (tokens []Token) {
+	var (
+		fence  string
+		offset int
+	)
+
+	for _, line := range strings.SplitAfter(body, "\n") {
+		start := offset
+		offset += len(line)
+
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		switch {
+		case fence != "":
+			if strings.TrimSpace(line) == fence {
+				fence = ""
+			}
+
+			continue
+
+		case OpeningFence(line) != "":
+			fence = OpeningFence(line)
+
+			continue
+		}
+
+		// The * which starts a list item isn't a delimiter
+		var prefix int
+		if strings.HasPrefix(line, "* ") {
+			prefix = 2
+		}
+
+		var code []Span
+		for _, s := range Spans(line[prefix:]) {
+			if s.Delim == '`' {
+				code = append(code, Span{Delim: s.Delim, Start: s.Start + prefix, End: s.End + prefix})
+			}
+		}
+
+		for _, tok := range FindText(line) {
+			if inside(code, tok) {
+				continue
+			}
+
+			tokens = append(tokens, Token{Start: tok.Start + start, End: tok.End + start, Index: tok.Index})
+		}
+	}
+
+	return
+}
+
+// FindText returns every index link in s, in order, treating s as text with
+// no code in it. Tokens with indices too large to fit in an int can't refer
+// to anything, and are treated as text, as are escaped tokens
+func FindText(s string) (tokens []Token) {
+	for _, m := range pattern.FindAllStringSubmatchIndex(s, -1) {
+		idx, err := strconv.Atoi(s[m[2]:m[3]])
+		if err != nil || Escaped(s, m[0]) {
+			continue
+		}
+
+		tokens = append(tokens, Token{Start: m[0], End: m[1], Index: idx})
+	}
+
+	return
+}
+
+// Spans returns the emphasis and code spans within a single line of text, in
+// order. Delimiters without a partner, or with nothing between them, are
+// text, as are escaped delimiters, and delimiters within another span.
+// Backslashes within code are text, and so can't escape the ` closing it
+func Spans(line string) (spans []Span) {
+	for i := 0; i < len(line); i++ {
+		delim := line[i]
+
+		switch {
+		case delim == '\\' && i+1 < len(line) && IsEscapable(line[i+1]):
+			i++
+
+			continue
+
+		case delim != '*' && delim != '`':
+			continue
+		}
+
+		end := closing(line, i+1, delim)
+		if end <= i+1 {
+			continue
+		}
+
+		spans = append(spans, Span{Delim: delim, Start: i, End: end})
+		i = end
+	}
+
+	return
+}
+
+// OpeningFence returns the run of three or more backticks line starts with,
+// which opens a code block closed by a line of exactly the same run, or an
+// empty string where line doesn't open a code block
+func OpeningFence(line string) string {
+	n := len(line) - len(strings.TrimLeft(line, "`"))
+	if n < len(Fence) {
+		return ""
+	}
+
+	return line[:n]
+}
+
+// IsEscapable returns whether b, following a backslash, is text
+func IsEscapable(b byte) bool {
+	return strings.IndexByte(Escapable, b) >= 0
+}
+
+// Escaped returns whether s[i] is escaped, by an odd number of backslashes
+// immediately before it
+func Escaped(s string, i int) bool {
+	n := 0
+	for i-n > 0 && s[i-n-1] == '\\' {
+		n++
+	}
+
+	return n%2 == 1
+}
+
+// Format returns the index link for idx
+func Format(idx int) string {
+	return "[l:" + strconv.Itoa(idx) + "]"
+}
+
+// closing returns the index of the delim which closes a span opened before
+// from, or -1 where there isn't one
+func closing(line string, from int, delim byte) int {
+	if delim == '`' {
+		end := strings.IndexByte(line[from:], delim)
+		if end < 0 {
+			return -1
+		}
+
+		return end + from
+	}
+
+	for i := from; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && IsEscapable(line[i+1]):
+			i++
+
+		case line[i] == delim:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// inside returns whether tok falls within any of spans. Tokens never contain
+// delimiters, and so are either wholly inside a span or wholly outside it
+func inside(spans []Span, tok Token) bool {
+	for _, s := range spans {
+		if tok.Start > s.Start && tok.End <= s.End {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,17 @@
+// Package psk holds the DTLS configuration shared by gordon servers and
+// clients which authenticate each other with pre-shared keys
+package psk
+
+import (
+	"github.com/pion/dtls/v2"
+)
+
+// CipherSuites are the cipher suites offered, and accepted, when using
+// pre-shared keys, in order of preference. Suites which combine the
+// pre-shared key with an ephemeral key exchange come first, for forward
+// secrecy
+var CipherSuites = []dtls.CipherSuiteID{
+	dtls.TLS_ECDHE_PSK_WITH_AES_128_CBC_SHA256,
+	dtls.TLS_PSK_WITH_AES_128_GCM_SHA256,
+	dtls.TLS_PSK_WITH_AES_128_CCM,
+}
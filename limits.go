@@ -0,0 +1,185 @@
+package gordon
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sweepInterval is how often an addressLimiter forgets about addresses
+// which have nothing in flight and a full bucket of tokens, so that the
+// set of addresses it tracks doesn't grow forever
+const sweepInterval = time.Minute
+
+// LimitStats counts the connections a Listener has turned away for
+// exceeding its per-address limits, since ListenAndServe was called
+type LimitStats struct {
+	// RateLimited is the number of connections dropped because their
+	// address had exceeded RateLimit
+	RateLimited uint64
+
+	// QuotaExceeded is the number of connections dropped because their
+	// address already had MaxConnectionsPerAddress connections in flight
+	QuotaExceeded uint64
+}
+
+// addressLimiter applies a token bucket rate limit, and a cap on concurrent
+// connections, to each remote IP address separately
+type addressLimiter struct {
+	rate          float64
+	burst         float64
+	maxConcurrent int64
+
+	rateLimited   atomic.Uint64
+	quotaExceeded atomic.Uint64
+
+	mu        sync.Mutex
+	addresses map[string]*addressState
+	lastSweep time.Time
+
+	// now is swapped out in tests
+	now func() time.Time
+}
+
+type addressState struct {
+	tokens float64
+	last   time.Time
+	active int64
+}
+
+// newAddressLimiter returns an addressLimiter allowing rate new connections
+// per second from each address, with bursts of up to burst connections, and
+// up to maxConcurrent connections in flight per address. Limits of zero or
+// less are disabled
+func newAddressLimiter(rate float64, burst int, maxConcurrent int64) *addressLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &addressLimiter{
+		rate:          rate,
+		burst:         float64(burst),
+		maxConcurrent: maxConcurrent,
+		addresses:     make(map[string]*addressState),
+		now:           time.Now,
+	}
+}
+
+// enabled returns whether any limit is set, so that Listeners without limits
+// needn't track addresses at all
+func (a *addressLimiter) enabled() bool {
+	return a.rate > 0 || a.maxConcurrent > 0
+}
+
+// admit decides whether a new connection from addr is allowed, returning a
+// function to call once the connection is finished with where it is, and
+// an error explaining why where it isn't
+func (a *addressLimiter) admit(addr net.Addr) (release func(), err error) {
+	if !a.enabled() {
+		return func() {}, nil
+	}
+
+	key := addressKey(addr)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	a.sweep(now)
+
+	s, ok := a.addresses[key]
+	if !ok {
+		s = &addressState{tokens: a.burst, last: now}
+		a.addresses[key] = s
+	}
+
+	if a.rate > 0 {
+		s.refill(now, a.rate, a.burst)
+
+		if s.tokens < 1 {
+			a.rateLimited.Add(1)
+
+			return nil, ErrRateLimited
+		}
+	}
+
+	if a.maxConcurrent > 0 && s.active >= a.maxConcurrent {
+		a.quotaExceeded.Add(1)
+
+		return nil, ErrTooManyConnections
+	}
+
+	// Only spend a token once we know the connection is going
+	// ahead, so that connections turned away for being over quota
+	// don't also eat into the rate limit
+	if a.rate > 0 {
+		s.tokens--
+	}
+
+	s.active++
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+
+			s.active--
+		})
+	}, nil
+}
+
+// stats returns the number of connections turned away so far
+func (a *addressLimiter) stats() LimitStats {
+	return LimitStats{
+		RateLimited:   a.rateLimited.Load(),
+		QuotaExceeded: a.quotaExceeded.Load(),
+	}
+}
+
+// sweep forgets addresses which are indistinguishable from addresses
+// never seen before, and must be called with mu held
+func (a *addressLimiter) sweep(now time.Time) {
+	if now.Sub(a.lastSweep) < sweepInterval {
+		return
+	}
+
+	a.lastSweep = now
+
+	for key, s := range a.addresses {
+		if a.rate > 0 {
+			s.refill(now, a.rate, a.burst)
+		}
+
+		if s.active == 0 && (a.rate <= 0 || s.tokens >= a.burst) {
+			delete(a.addresses, key)
+		}
+	}
+}
+
+// refill adds the tokens earned since s was last refilled, up to burst
+func (s *addressState) refill(now time.Time, rate, burst float64) {
+	s.tokens += now.Sub(s.last).Seconds() * rate
+	if s.tokens > burst {
+		s.tokens = burst
+	}
+
+	s.last = now
+}
+
+// addressKey returns the IP address of addr, so that limits apply to hosts
+// rather than to individual ports
+func addressKey(addr net.Addr) string {
+	if udp, ok := addr.(*net.UDPAddr); ok {
+		return udp.IP.String()
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+
+	return host
+}
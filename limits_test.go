@@ -0,0 +1,142 @@
+package gordon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jspc/gordon/client"
+	"github.com/pion/dtls/v2/pkg/crypto/selfsign"
+)
+
+func TestAddressLimiter_Admit(t *testing.T) {
+	a, _ := net.ResolveUDPAddr("udp", "192.0.2.1:1234")
+	aOtherPort, _ := net.ResolveUDPAddr("udp", "192.0.2.1:5678")
+	b, _ := net.ResolveUDPAddr("udp", "192.0.2.2:1234")
+
+	t.Run("Rate limits apply per address, and refill over time", func(t *testing.T) {
+		now := time.Now()
+
+		l := newAddressLimiter(1, 2, 0)
+		l.now = func() time.Time { return now }
+
+		for _, test := range []struct {
+			addr   net.Addr
+			expect error
+		}{
+			{a, nil},
+			{aOtherPort, nil},
+			{a, ErrRateLimited},
+			{b, nil},
+		} {
+			release, err := l.admit(test.addr)
+			if !errors.Is(err, test.expect) {
+				t.Errorf("%s: expected %v, received %v", test.addr, test.expect, err)
+			}
+
+			if release != nil {
+				release()
+			}
+		}
+
+		now = now.Add(time.Second)
+
+		_, err := l.admit(a)
+		if err != nil {
+			t.Errorf("expected bucket to refill, received %v", err)
+		}
+
+		if l.stats().RateLimited != 1 {
+			t.Errorf("expected 1 rate limited connection, received %d", l.stats().RateLimited)
+		}
+	})
+
+	t.Run("Concurrent connections are capped per address", func(t *testing.T) {
+		l := newAddressLimiter(0, 0, 1)
+
+		release, err := l.admit(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = l.admit(aOtherPort)
+		if !errors.Is(err, ErrTooManyConnections) {
+			t.Errorf("expected ErrTooManyConnections, received %v", err)
+		}
+
+		_, err = l.admit(b)
+		if err != nil {
+			t.Errorf("unexpected error for other address: %v", err)
+		}
+
+		// Releasing twice mustn't free up somebody else's slot
+		release()
+		release()
+
+		_, err = l.admit(a)
+		if err != nil {
+			t.Errorf("expected slot to be released, received %v", err)
+		}
+
+		_, err = l.admit(a)
+		if !errors.Is(err, ErrTooManyConnections) {
+			t.Errorf("expected ErrTooManyConnections, received %v", err)
+		}
+
+		if l.stats().QuotaExceeded != 2 {
+			t.Errorf("expected 2 over quota connections, received %d", l.stats().QuotaExceeded)
+		}
+	})
+
+	t.Run("Idle addresses are forgotten", func(t *testing.T) {
+		now := time.Now()
+
+		l := newAddressLimiter(10, 1, 0)
+		l.now = func() time.Time { return now }
+
+		release, _ := l.admit(a)
+		release()
+
+		now = now.Add(sweepInterval)
+
+		//#nosec: G104
+		l.admit(b)
+
+		if _, ok := l.addresses[addressKey(a)]; ok {
+			t.Error("expected idle address to be forgotten")
+		}
+	})
+}
+
+func TestListener_ListenAndServe_RateLimit(t *testing.T) {
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	l, _ := NewListener(new(dummyHandler), cert)
+	l.RateLimit = 0.01
+	l.RateBurst = 1
+
+	defer l.Close()
+
+	startListener(t, &l, "localhost:4477")
+
+	addr, _ := client.ParseAddress("//localhost:4477/")
+
+	c, _ := client.NewClient()
+	c.DialTimeout = time.Millisecond * 500
+
+	_, err := c.Get(context.Background(), addr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = c.Get(context.Background(), addr)
+	if err == nil {
+		t.Error("expected second request to be dropped")
+	}
+
+	if l.LimitStats().RateLimited == 0 {
+		t.Error("expected rate limited connections to be counted")
+	}
+}
@@ -0,0 +1,276 @@
+package gordon
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/jspc/gordon/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// An AccessLogField is a field which may be included in the line a Listener
+// logs for each request
+type AccessLogField string
+
+// The fields which may be included in access logs
+const (
+	AccessLogVerb          AccessLogField = "verb"
+	AccessLogDocument      AccessLogField = "document"
+	AccessLogRemoteAddress AccessLogField = "remote_address"
+	AccessLogDuration      AccessLogField = "duration"
+	AccessLogIsError       AccessLogField = "is_error"
+	AccessLogStatus        AccessLogField = "status"
+	AccessLogSize          AccessLogField = "size"
+	AccessLogError         AccessLogField = "error"
+	AccessLogSubject       AccessLogField = "subject"
+	AccessLogPSKIdentity   AccessLogField = "psk_identity"
+)
+
+// DefaultAccessLogFields are the fields every Listener includes in its
+// access logs, unless told otherwise
+var DefaultAccessLogFields = []AccessLogField{
+	AccessLogVerb,
+	AccessLogDocument,
+	AccessLogRemoteAddress,
+	AccessLogDuration,
+	AccessLogIsError,
+	AccessLogSize,
+	AccessLogError,
+}
+
+// accessLogEntry holds everything which may be logged about a request
+type accessLogEntry struct {
+	req      *types.Request
+	resp     *types.Page
+	err      error
+	duration time.Duration
+
+	// size is the size of the response sent, or less than zero where
+	// that isn't known
+	size int
+}
+
+// isError returns whether the request failed, or was answered with an
+// error page
+func (e accessLogEntry) isError() bool {
+	return e.err != nil || e.resp == nil || e.resp.Status.IsError()
+}
+
+// logRequest logs e to logger, at info level, including each of fields
+func logRequest(ctx context.Context, logger *slog.Logger, e accessLogEntry, fields []AccessLogField) {
+	peer, _ := PeerFromContext(ctx)
+
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		key := string(f)
+
+		switch f {
+		case AccessLogVerb:
+			attrs = append(attrs, slog.String(key, verbToString(e.req.Verb)))
+
+		case AccessLogDocument:
+			attrs = append(attrs, slog.String(key, e.req.ID.String()))
+
+		case AccessLogRemoteAddress:
+			var remoteAddress string
+			if peer.RemoteAddr != nil {
+				remoteAddress = peer.RemoteAddr.String()
+			}
+
+			attrs = append(attrs, slog.String(key, remoteAddress))
+
+		case AccessLogDuration:
+			attrs = append(attrs, slog.Duration(key, e.duration))
+
+		case AccessLogIsError:
+			attrs = append(attrs, slog.Bool(key, e.isError()))
+
+		case AccessLogStatus:
+			status := "none"
+			if e.resp != nil {
+				status = statusToString(e.resp.Status)
+			}
+
+			attrs = append(attrs, slog.String(key, status))
+
+		case AccessLogSize:
+			if e.size >= 0 {
+				attrs = append(attrs, slog.Int(key, e.size))
+			}
+
+		case AccessLogError:
+			if e.err != nil {
+				attrs = append(attrs, slog.Any(key, e.err))
+			}
+
+		case AccessLogSubject:
+			attrs = append(attrs, slog.String(key, peer.Subject))
+
+		case AccessLogPSKIdentity:
+			attrs = append(attrs, slog.String(key, peer.PSKIdentity))
+		}
+	}
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "Request", attrs...)
+}
+
+// sampler decides which successful requests are logged, where only one in
+// every n should be
+type sampler struct {
+	count atomic.Uint64
+}
+
+// sample returns whether a request should be logged. Errored requests are
+// always logged; of the rest, every nth is, where n is greater than 1
+func (s *sampler) sample(n int, isError bool) bool {
+	if isError || n <= 1 {
+		return true
+	}
+
+	return s.count.Add(1)%uint64(n) == 1
+}
+
+// NewZapHandler returns a slog.Handler which writes to logger, allowing
+// a zap.Logger to be used wherever gordon expects a *slog.Logger
+//
+//	l.Logger = slog.New(gordon.NewZapHandler(zapLogger))
+func NewZapHandler(logger *zap.Logger) slog.Handler {
+	return zapHandler{logger: logger}
+}
+
+// zapHandler writes slog records to a zap.Logger. Groups are flattened
+// into dotted keys
+type zapHandler struct {
+	logger *zap.Logger
+	prefix string
+}
+
+// Enabled fulfills the slog.Handler interface
+func (h zapHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Core().Enabled(zapLevel(level))
+}
+
+// Handle fulfills the slog.Handler interface
+func (h zapHandler) Handle(_ context.Context, r slog.Record) error {
+	ce := h.logger.Check(zapLevel(r.Level), r.Message)
+	if ce == nil {
+		return nil
+	}
+
+	if !r.Time.IsZero() {
+		ce.Time = r.Time
+	}
+
+	// zap would otherwise report every line as logged from here, rather
+	// than from wherever the slog.Logger was called
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ce.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+	}
+
+	fields := make([]zap.Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields = h.appendFields(fields, h.prefix, a)
+
+		return true
+	})
+
+	ce.Write(fields...)
+
+	return nil
+}
+
+// WithAttrs fulfills the slog.Handler interface
+func (h zapHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zap.Field, 0, len(attrs))
+	for _, a := range attrs {
+		fields = h.appendFields(fields, h.prefix, a)
+	}
+
+	return zapHandler{
+		logger: h.logger.With(fields...),
+		prefix: h.prefix,
+	}
+}
+
+// WithGroup fulfills the slog.Handler interface
+func (h zapHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return zapHandler{
+		logger: h.logger,
+		prefix: h.prefix + name + ".",
+	}
+}
+
+// appendFields appends a as one or more zap fields, with keys prefixed by
+// prefix
+func (h zapHandler) appendFields(fields []zap.Field, prefix string, a slog.Attr) []zap.Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	key := prefix + a.Key
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		// Groups with empty keys are inlined, as per the slog.Handler
+		// documentation
+		if a.Key != "" {
+			prefix = key + "."
+		}
+
+		for _, ga := range a.Value.Group() {
+			fields = h.appendFields(fields, prefix, ga)
+		}
+
+		return fields
+
+	case slog.KindString:
+		return append(fields, zap.String(key, a.Value.String()))
+
+	case slog.KindInt64:
+		return append(fields, zap.Int64(key, a.Value.Int64()))
+
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(key, a.Value.Uint64()))
+
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(key, a.Value.Float64()))
+
+	case slog.KindBool:
+		return append(fields, zap.Bool(key, a.Value.Bool()))
+
+	case slog.KindDuration:
+		return append(fields, zap.Duration(key, a.Value.Duration()))
+
+	case slog.KindTime:
+		return append(fields, zap.Time(key, a.Value.Time()))
+	}
+
+	if err, ok := a.Value.Any().(error); ok {
+		return append(fields, zap.NamedError(key, err))
+	}
+
+	return append(fields, zap.Any(key, a.Value.Any()))
+}
+
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	}
+
+	return zapcore.DebugLevel
+}
@@ -0,0 +1,137 @@
+package gordon
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jspc/gordon/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewZapHandler(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	logger := slog.New(NewZapHandler(zap.New(core))).
+		With("component", "test").
+		WithGroup("req")
+
+	logger.Debug("dropped")
+	logger.Warn("kept",
+		slog.Int("count", 3),
+		slog.Any("error", errors.New("oh no")),
+		slog.Group("peer", slog.String("subject", "someone")),
+	)
+
+	if logs.Len() != 1 {
+		t.Fatalf("expected 1 log line, received %d", logs.Len())
+	}
+
+	entry := logs.All()[0]
+	if entry.Level != zapcore.WarnLevel {
+		t.Errorf("expected level %v, received %v", zapcore.WarnLevel, entry.Level)
+	}
+
+	if !strings.HasSuffix(entry.Caller.File, "logging_test.go") {
+		t.Errorf("expected caller to be the test, received %s", entry.Caller.File)
+	}
+
+	fields := entry.ContextMap()
+	for k, v := range map[string]any{
+		"component":        "test",
+		"req.count":        int64(3),
+		"req.error":        "oh no",
+		"req.peer.subject": "someone",
+	} {
+		if fields[k] != v {
+			t.Errorf("%s: expected %#v, received %#v", k, v, fields[k])
+		}
+	}
+}
+
+func TestLogRequest_Fields(t *testing.T) {
+	addr, _ := net.ResolveUDPAddr("udp", "127.0.0.1:1234")
+	ctx := withPeer(context.Background(), Peer{RemoteAddr: addr, PSKIdentity: "client-1"})
+
+	entry := accessLogEntry{
+		req:      &types.Request{Verb: types.VerbRead},
+		resp:     &types.Page{Status: types.StatusOK},
+		duration: time.Millisecond,
+		size:     -1,
+	}
+
+	for _, test := range []struct {
+		name         string
+		fields       []AccessLogField
+		expectFields map[string]any
+	}{
+		{"Defaults omit unknown sizes and nil errors", DefaultAccessLogFields, map[string]any{
+			"verb":           "read",
+			"document":       "00000000-0000-0000-0000-000000000000",
+			"remote_address": "127.0.0.1:1234",
+			"duration":       time.Millisecond,
+			"is_error":       false,
+		}},
+		{"Chosen fields only", []AccessLogField{AccessLogStatus, AccessLogPSKIdentity}, map[string]any{
+			"status":       "ok",
+			"psk_identity": "client-1",
+		}},
+		{"No fields", nil, map[string]any{}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.InfoLevel)
+
+			logRequest(ctx, slog.New(NewZapHandler(zap.New(core))), entry, test.fields)
+
+			if logs.Len() != 1 {
+				t.Fatalf("expected 1 log line, received %d", logs.Len())
+			}
+
+			fields := logs.All()[0].ContextMap()
+			if len(fields) != len(test.expectFields) {
+				t.Errorf("expected %d fields, received %#v", len(test.expectFields), fields)
+			}
+
+			for k, v := range test.expectFields {
+				if fields[k] != v {
+					t.Errorf("%s: expected %#v, received %#v", k, v, fields[k])
+				}
+			}
+		})
+	}
+}
+
+func TestSampler_Sample(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		n       int
+		isError bool
+		expect  int
+	}{
+		{"Disabled", 0, false, 100},
+		{"Every request", 1, false, 100},
+		{"One in ten", 10, false, 10},
+		{"Errors are always logged", 10, true, 100},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := new(sampler)
+
+			var logged int
+			for i := 0; i < 100; i++ {
+				if s.sample(test.n, test.isError) {
+					logged++
+				}
+			}
+
+			if logged != test.expect {
+				t.Errorf("expected %d requests to be logged, received %d", test.expect, logged)
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jspc/gordon/markup"
+	"github.com/jspc/gordon/types"
+)
+
+// introduction is the title of the section holding anything before the
+// first heading of a document
+const introduction = "Introduction"
+
+var (
+	atxHeading    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextHeading = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	thematicBreak = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	codeFence     = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*([^`\\s]*)")
+	listItem      = regexp.MustCompile(`^[ \t]*(?:[-*+]|\d{1,9}[.)])(?:[ \t]+(.*))?$`)
+	blockQuote    = regexp.MustCompile(`^ {0,3}>[ \t]?(.*)$`)
+	refDefinition = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:[ \t]*<?([^\s>]+)>?(?:[ \t]+.*)?$`)
+)
+
+type blockKind uint8
+
+const (
+	headingBlock blockKind = iota
+	paragraphBlock
+	listBlock
+	quoteBlock
+	codeBlock
+)
+
+// block is a block of Markdown, in the shape of the markup it is converted
+// to
+type block struct {
+	kind  blockKind
+	level int
+	lang  string
+
+	// lines are the lines of a paragraph, quote, or code block, the
+	// items of a list, or the text of a heading
+	lines []string
+
+	// hardBreak is set where the last line ended with a hard line break,
+	// and so the next line isn't joined to it
+	hardBreak bool
+}
+
+// join adds s to the last line of b, as Markdown joins lines which have
+// been wrapped, unless the last line ended with a hard line break
+func (b *block) join(s string) {
+	hard := strings.HasSuffix(s, "  ") || strings.HasSuffix(s, `\`)
+
+	s = strings.TrimSpace(strings.TrimSuffix(s, `\`))
+
+	switch {
+	case len(b.lines) == 0 || b.hardBreak:
+		b.lines = append(b.lines, s)
+
+	case b.lines[len(b.lines)-1] == "":
+		b.lines[len(b.lines)-1] = s
+
+	default:
+		b.lines[len(b.lines)-1] += " " + s
+	}
+
+	b.hardBreak = hard
+}
+
+type converter struct {
+	importer Importer
+	from     string
+	page     *types.Page
+
+	// links maps each PageRef in page.Links to its index, so that pages
+	// linked to more than once only appear in Links once
+	links map[types.PageRef]int
+
+	// refs are the destinations of reference links, by label
+	refs map[string]string
+}
+
+// convert converts src, setting the Title, Sections, and Links of page
+func (c *converter) convert(src string) {
+	blocks := c.parse(src)
+
+	// A level one heading at the very start of a document is its title,
+	// unless the front matter sets one; in which case it's only dropped
+	// where it says the same thing
+	if len(blocks) > 0 && blocks[0].kind == headingBlock && blocks[0].level == 1 {
+		title := c.inline(blocks[0].lines[0], true)
+
+		if c.page.Title == "" || c.page.Title == title {
+			c.page.Title = title
+			blocks = blocks[1:]
+		}
+	}
+
+	sectionLevel := 0
+	for _, b := range blocks {
+		if b.kind == headingBlock && (sectionLevel == 0 || b.level < sectionLevel) {
+			sectionLevel = b.level
+		}
+	}
+
+	var (
+		title   = introduction
+		body    []string
+		titles  = make(map[string]int)
+		started bool
+	)
+
+	flush := func() {
+		// Documents without anything before their first heading
+		// don't need an Introduction
+		if !started && len(body) == 0 {
+			return
+		}
+
+		// Section titles must be unique, and Markdown headings
+		// needn't be
+		titles[title]++
+		if n := titles[title]; n > 1 {
+			title += " (" + strconv.Itoa(n) + ")"
+		}
+
+		// Round trip bodies through markup, so that they're stored
+		// in canonical form
+		blocks, _ := markup.Parse(strings.Join(body, "\n\n"))
+
+		c.page.Sections = append(c.page.Sections, types.Section{
+			Title: title,
+			Body:  markup.Serialize(blocks),
+		})
+	}
+
+	for _, b := range blocks {
+		if b.kind == headingBlock && b.level == sectionLevel {
+			flush()
+
+			title = c.inline(b.lines[0], true)
+			body = nil
+			started = true
+
+			continue
+		}
+
+		if s := c.markup(b, sectionLevel); s != "" {
+			body = append(body, s)
+		}
+	}
+
+	flush()
+}
+
+// markup returns b as markup, where headings are nested below sections
+// made from headings of sectionLevel
+func (c *converter) markup(b *block, sectionLevel int) string {
+	var lines []string
+
+	switch b.kind {
+	case headingBlock:
+		text := c.inline(b.lines[0], false)
+		if text == "" {
+			return ""
+		}
+
+		return strings.Repeat("#", min(b.level-sectionLevel, markup.MaxHeadingLevel)) + " " + text
+
+	case paragraphBlock, listBlock, quoteBlock:
+		prefix := map[blockKind]string{listBlock: "* ", quoteBlock: "> "}[b.kind]
+
+		for _, l := range b.lines {
+			if l = c.inline(l, false); l != "" {
+				lines = append(lines, prefix+l)
+			}
+		}
+
+	case codeBlock:
+		code := b.lines
+		for len(code) > 0 && strings.TrimSpace(code[len(code)-1]) == "" {
+			code = code[:len(code)-1]
+		}
+
+		// Code may itself contain lines of ```, and so is fenced
+		// with however many backticks it takes
+		fence := markup.CodeFence(strings.Join(code, "\n"))
+
+		lines = append(append([]string{fence + b.lang}, code...), fence)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// parse splits body into blocks, collecting reference link definitions as
+// it goes
+func (c *converter) parse(body string) (blocks []*block) {
+	c.refs = make(map[string]string)
+
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+
+	// open is the block further lines may be added to
+	var open *block
+
+	add := func(b *block) {
+		blocks = append(blocks, b)
+		open = b
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := codeFence.FindStringSubmatch(line); m != nil {
+			b := &block{kind: codeBlock, lang: m[2]}
+
+			for i++; i < len(lines) && !closesFence(lines[i], m[1]); i++ {
+				b.lines = append(b.lines, lines[i])
+			}
+
+			add(b)
+			open = nil
+
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			if open != nil && open.kind == codeBlock {
+				open.lines = append(open.lines, "")
+
+				continue
+			}
+
+			open = nil
+
+			continue
+		}
+
+		if open != nil && open.kind == codeBlock && indented(line) {
+			open.lines = append(open.lines, unindent(line))
+
+			continue
+		}
+
+		if m := refDefinition.FindStringSubmatch(line); m != nil && (open == nil || open.kind != paragraphBlock) {
+			c.refs[refLabel(m[1])] = m[2]
+			open = nil
+
+			continue
+		}
+
+		if m := atxHeading.FindStringSubmatch(line); m != nil {
+			add(&block{kind: headingBlock, level: len(m[1]), lines: []string{m[2]}})
+			open = nil
+
+			continue
+		}
+
+		if m := setextHeading.FindStringSubmatch(line); m != nil && open != nil && open.kind == paragraphBlock {
+			open.kind = headingBlock
+			open.level = map[byte]int{'=': 1, '-': 2}[m[1][0]]
+			open.lines = []string{strings.Join(open.lines, " ")}
+			open = nil
+
+			continue
+		}
+
+		if thematicBreak.MatchString(line) {
+			open = nil
+
+			continue
+		}
+
+		if m := listItem.FindStringSubmatch(line); m != nil {
+			if open == nil || open.kind != listBlock {
+				add(&block{kind: listBlock})
+			}
+
+			open.lines = append(open.lines, "")
+			open.hardBreak = false
+			open.join(m[1])
+
+			continue
+		}
+
+		// Indented lines after a list, even after a blank line, carry
+		// on its last item
+		if open == nil && indented(line) && len(blocks) > 0 && blocks[len(blocks)-1].kind == listBlock {
+			open = blocks[len(blocks)-1]
+			open.join(line)
+
+			continue
+		}
+
+		if open == nil && indented(line) {
+			add(&block{kind: codeBlock, lines: []string{unindent(line)}})
+
+			continue
+		}
+
+		if m := blockQuote.FindStringSubmatch(line); m != nil {
+			if open == nil || open.kind != quoteBlock {
+				add(&block{kind: quoteBlock})
+			}
+
+			// Nested quotes are flattened, and blank lines within
+			// a quote start a new line
+			content := strings.TrimLeft(m[1], "> \t")
+			if content == "" {
+				open.lines = append(open.lines, "")
+				open.hardBreak = false
+
+				continue
+			}
+
+			open.join(content)
+
+			continue
+		}
+
+		// Anything else carries on a paragraph, list item, or quote, or
+		// starts a new paragraph
+		if open == nil || open.kind == codeBlock {
+			add(&block{kind: paragraphBlock})
+		}
+
+		open.join(line)
+	}
+
+	return
+}
+
+func closesFence(line, fence string) bool {
+	line = strings.TrimSpace(line)
+
+	return strings.HasPrefix(line, fence) && strings.Trim(line, fence[:1]) == ""
+}
+
+func indented(line string) bool {
+	return strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")
+}
+
+func unindent(line string) string {
+	if strings.HasPrefix(line, "\t") {
+		return line[1:]
+	}
+
+	return strings.TrimPrefix(line, "    ")
+}
+
+// refLabel normalises reference link labels, which match case
+// insensitively and regardless of whitespace
+func refLabel(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,345 @@
+package markdown
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jspc/gordon/markup"
+)
+
+// inline converts the inline Markdown in s to markup or, where plain is
+// set, to plain text, such as for titles
+func (c *converter) inline(s string, plain bool) string {
+	return c.inlineText(s, plain, false)
+}
+
+// inlineText converts s as inline does where, since emphasis in markup
+// can't be nested, any emphasis within s is dropped where emphasised is set
+func (c *converter) inlineText(s string, plain, emphasised bool) string {
+	sb := new(strings.Builder)
+
+	for i := 0; i < len(s); {
+		ch := s[i]
+
+		switch {
+		case ch == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			sb.WriteString(escape(s[i+1:i+2], plain))
+			i += 2
+
+			continue
+
+		case ch == '`':
+			n := runLength(s, i)
+
+			end := findRun(s, i+n, '`', n)
+			if end < 0 {
+				sb.WriteString(escape(s[i:i+n], plain))
+				i += n
+
+				continue
+			}
+
+			code := s[i+n : end]
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+				code = code[1 : len(code)-1]
+			}
+
+			// Markup has no way of writing code containing
+			// backticks, and so it's kept as text
+			if plain || strings.Contains(code, "`") {
+				sb.WriteString(escape(code, plain))
+			} else {
+				sb.WriteString("`" + code + "`")
+			}
+
+			i = end + n
+
+			continue
+
+		case ch == '!' && i+1 < len(s) && s[i+1] == '[':
+			// Images can't be shown, and so are replaced with their
+			// alt text
+			if alt, _, end, ok := c.parseLink(s, i+1); ok {
+				sb.WriteString(escape(c.inline(alt, true), plain))
+				i = end
+
+				continue
+			}
+
+		case ch == '[':
+			if label, dest, end, ok := c.parseLink(s, i); ok {
+				sb.WriteString(c.link(c.inlineText(label, plain, emphasised), dest, plain))
+				i = end
+
+				continue
+			}
+
+		case ch == '<':
+			if end := strings.IndexByte(s[i:], '>'); end > 0 && isAutolink(s[i+1:i+end]) {
+				dest := s[i+1 : i+end]
+
+				sb.WriteString(c.link("", dest, plain))
+				i += end + 1
+
+				continue
+			}
+
+		case ch == '*' || ch == '_':
+			n := runLength(s, i)
+
+			if n <= 3 && opensEmphasis(s, i, n) {
+				if end := closeEmphasis(s, i+n, ch, n); end >= 0 {
+					inner := c.inlineText(s[i+n:end], plain, true)
+					if plain || emphasised {
+						sb.WriteString(inner)
+					} else {
+						sb.WriteString("*" + inner + "*")
+					}
+
+					i = end + n
+
+					continue
+				}
+			}
+
+			sb.WriteString(escape(s[i:i+n], plain))
+			i += n
+
+			continue
+		}
+
+		sb.WriteString(escape(s[i:i+1], plain))
+		i++
+	}
+
+	return sb.String()
+}
+
+// escape returns s, which is plain text, escaped as markup unless plain is
+// set
+func escape(s string, plain bool) string {
+	if plain {
+		return s
+	}
+
+	return markup.Escape(s)
+}
+
+// link returns a link with label, which is already markup, to dest as markup
+// which, where dest is a page, is an index link to it in Links
+func (c *converter) link(label, dest string, plain bool) string {
+	if label == "" {
+		label = escape(dest, plain)
+	}
+
+	if plain {
+		return label
+	}
+
+	ref, ok := c.importer.ref(c.from, dest)
+	if !ok {
+		if label == escape(dest, plain) {
+			return label
+		}
+
+		return label + " (" + escape(dest, plain) + ")"
+	}
+
+	idx, ok := c.links[ref]
+	if !ok {
+		idx = len(c.page.Links)
+		c.links[ref] = idx
+		c.page.Links = append(c.page.Links, ref)
+	}
+
+	token := "[l:" + strconv.Itoa(idx) + "]"
+	if label == escape(dest, plain) {
+		return token
+	}
+
+	return label + " " + token
+}
+
+// parseLink parses the link starting with the [ at s[i], returning its text
+// and destination, and the index of the first byte after it. Inline links,
+// such as [text](dest), and reference links, such as [text][ref], [ref][]
+// and [ref], are supported
+func (c *converter) parseLink(s string, i int) (text, dest string, end int, ok bool) {
+	closing := matching(s, i, '[', ']')
+	if closing < 0 {
+		return
+	}
+
+	text = s[i+1 : closing]
+	end = closing + 1
+
+	switch {
+	case end < len(s) && s[end] == '(':
+		paren := matching(s, end, '(', ')')
+		if paren < 0 {
+			return
+		}
+
+		dest = strings.TrimSpace(s[end+1 : paren])
+		if strings.HasPrefix(dest, "<") {
+			dest, _, _ = strings.Cut(dest[1:], ">")
+		} else if f := strings.Fields(dest); len(f) > 0 {
+			dest = f[0]
+		}
+
+		return text, dest, paren + 1, true
+
+	case end < len(s) && s[end] == '[':
+		labelEnd := strings.IndexByte(s[end:], ']')
+		if labelEnd < 0 {
+			return
+		}
+
+		label := s[end+1 : end+labelEnd]
+		if label == "" {
+			label = text
+		}
+
+		dest, ok = c.refs[refLabel(label)]
+
+		return text, dest, end + labelEnd + 1, ok
+	}
+
+	dest, ok = c.refs[refLabel(text)]
+
+	return text, dest, end, ok
+}
+
+// matching returns the index of the close which matches the open at s[i],
+// or -1 where there isn't one
+func matching(s string, i int, open, close byte) int {
+	depth := 0
+
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+
+		case open:
+			depth++
+
+		case close:
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+
+	return -1
+}
+
+// runLength returns the number of times s[i] repeats from i
+func runLength(s string, i int) int {
+	n := 1
+	for i+n < len(s) && s[i+n] == s[i] {
+		n++
+	}
+
+	return n
+}
+
+// findRun returns the index of the next run of exactly n ch in s, from i,
+// or -1 where there isn't one
+func findRun(s string, i int, ch byte, n int) int {
+	for i < len(s) {
+		if s[i] != ch {
+			i++
+
+			continue
+		}
+
+		m := runLength(s, i)
+		if m == n {
+			return i
+		}
+
+		i += m
+	}
+
+	return -1
+}
+
+// opensEmphasis returns whether the run of n delimiters at s[i] can open
+// emphasis; they must be followed by something other than whitespace and,
+// for underscores, not be in the middle of a word
+func opensEmphasis(s string, i, n int) bool {
+	if i+n >= len(s) || isSpace(s[i+n]) {
+		return false
+	}
+
+	return s[i] != '_' || i == 0 || !isWord(s[:i], true)
+}
+
+// closeEmphasis returns the index of the run of n ch, from i, which closes
+// emphasis, or -1 where there isn't one
+func closeEmphasis(s string, i int, ch byte, n int) int {
+	for {
+		j := findRun(s, i, ch, n)
+		if j < 0 {
+			return -1
+		}
+
+		// Escaped delimiters are text, and so can't close anything
+		if escaped(s, j) {
+			i = j + 1
+
+			continue
+		}
+
+		if j > i && !isSpace(s[j-1]) && (ch != '_' || j+n == len(s) || !isWord(s[j+n:], false)) {
+			return j
+		}
+
+		i = j + n
+	}
+}
+
+// isWord returns whether the rune at the end of s, where last is set, or
+// the start, where it isn't, is a letter or digit
+func isWord(s string, last bool) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	if last {
+		r, _ = utf8.DecodeLastRuneInString(s)
+	}
+
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// escaped returns whether s[i] is escaped, by an odd number of backslashes
+// immediately before it
+func escaped(s string, i int) bool {
+	n := 0
+	for i-n > 0 && s[i-n-1] == '\\' {
+		n++
+	}
+
+	return n%2 == 1
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n'
+}
+
+func isPunct(b byte) bool {
+	return b < utf8.RuneSelf && unicode.IsPunct(rune(b)) || strings.IndexByte("$+<=>^`|~", b) >= 0
+}
+
+// isAutolink returns whether s, found between < and >, is a URL, email
+// address, or gordon address such as //example.com/<id>
+func isAutolink(s string) bool {
+	if s == "" || strings.ContainsAny(s, " \t<") {
+		return false
+	}
+
+	scheme, _, ok := strings.Cut(s, ":")
+
+	return ok && len(scheme) > 1 || strings.HasPrefix(s, "//") || strings.Contains(s, "@")
+}
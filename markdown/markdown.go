@@ -0,0 +1,236 @@
+// Package markdown imports Markdown documents as gordon pages, so that
+// documentation already written in Markdown can be served without being
+// rewritten by hand.
+//
+// Documents may start with YAML front matter, between two lines of ---,
+// setting any of:
+//
+//	id:        the page's ID, generated from its path where unset
+//	title:     the page's title
+//	author:    the page's author
+//	published: when the page was published
+//	revision:  the page's revision, a hash of the document where unset
+//	preamble:  the page's preamble
+//	tags:      a list of tags
+//	labels:    a map of labels
+//
+// Where the title isn't set, a level one heading at the very start of the
+// document is used instead and, failing that, the name of the file.
+//
+// The shallowest headings left in the document become the titles of
+// Sections, and deeper headings become headings within them. Anything
+// before the first heading goes in a section called Introduction.
+//
+// Markdown is converted to the markup described by the markup package:
+// lists, quotes, code blocks, emphasis, and code are kept, and paragraphs
+// are unwrapped onto single lines. Links to other pages become Links, with
+// an index link, such as [l:0], after the link's text; links to anything
+// else are kept as text, followed by their destination in brackets. Markdown
+// with no equivalent, such as images or tables, is kept as text, and text
+// which would otherwise be read as markup, such as \* or [l:0], is escaped
+package markdown
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jspc/gordon/types"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultNamespace is the namespace page IDs are generated in where an
+// Importer doesn't set its own
+var DefaultNamespace = uuid.NewV5(uuid.NamespaceURL, "https://github.com/jspc/gordon/markdown")
+
+// FrontMatter is the metadata documents may set at the top of the file,
+// as described in the package docs
+type FrontMatter struct {
+	ID        uuid.UUID         `yaml:"id"`
+	Title     string            `yaml:"title"`
+	Author    string            `yaml:"author"`
+	Published time.Time         `yaml:"published"`
+	Revision  string            `yaml:"revision"`
+	Preamble  string            `yaml:"preamble"`
+	Tags      []string          `yaml:"tags"`
+	Labels    map[string]string `yaml:"labels"`
+}
+
+// An Importer imports Markdown documents. The zero value is ready to use
+type Importer struct {
+	// Namespace is the namespace IDs are generated in for pages which
+	// don't declare their own, from their path. Where unset,
+	// DefaultNamespace is used
+	Namespace uuid.UUID
+
+	// Link, where set, returns the page a link in the document at from,
+	// to dest, points to, and whether it points to a page at all, in
+	// place of the default.
+	//
+	// By default, gordon addresses such as //example.com/<id> and /<id>
+	// point to that page, and relative links to other Markdown files point
+	// to the ID that file is generated with. Documents which link to each
+	// other this way should leave their IDs to be generated
+	Link func(from, dest string) (types.PageRef, bool)
+}
+
+// Import imports the document read from r, which is at path, with the
+// defaults of an Importer
+func Import(path string, r io.Reader) (*types.Page, error) {
+	return Importer{}.Import(path, r)
+}
+
+// ImportFile imports the document at path
+func (i Importer) ImportFile(path string) (*types.Page, error) {
+	//#nosec: G304
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	//#nosec: G104
+	defer f.Close()
+
+	return i.Import(path, f)
+}
+
+// Import imports the document read from r, which is at path. Paths are
+// used to generate IDs, and to resolve relative links, and so should be
+// relative to the root of the documentation, such as docs/intro.md.
+//
+// Where the imported page fails validation, it is returned along with the
+// error
+func (i Importer) Import(path string, r io.Reader) (p *types.Page, err error) {
+	doc, err := io.ReadAll(r)
+	if err != nil {
+		return
+	}
+
+	fm, body, err := frontMatter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	p = &types.Page{
+		Meta: types.Metadata{
+			ID:        fm.ID,
+			Author:    fm.Author,
+			Published: fm.Published,
+			Revision:  fm.Revision,
+		},
+		Title:    fm.Title,
+		Preamble: fm.Preamble,
+		Tags:     fm.Tags,
+		Labels:   fm.Labels,
+		Status:   types.StatusOK,
+	}
+
+	if p.Meta.ID.IsNil() {
+		p.Meta.ID = i.ID(path)
+	}
+
+	if p.Meta.Revision == "" {
+		sum := sha256.Sum256(doc)
+		p.Meta.Revision = hex.EncodeToString(sum[:16])
+	}
+
+	c := converter{
+		importer: i,
+		from:     cleanPath(path),
+		page:     p,
+		links:    make(map[types.PageRef]int),
+	}
+
+	c.convert(body)
+
+	if p.Title == "" {
+		p.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	return p, p.Validate()
+}
+
+// ID returns the ID generated for the page at path
+func (i Importer) ID(path string) uuid.UUID {
+	ns := i.Namespace
+	if ns.IsNil() {
+		ns = DefaultNamespace
+	}
+
+	return uuid.NewV5(ns, cleanPath(path))
+}
+
+// ref returns the page a link from the document at from to dest points to
+func (i Importer) ref(from, dest string) (types.PageRef, bool) {
+	if i.Link != nil {
+		return i.Link(from, dest)
+	}
+
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" {
+		return types.PageRef{}, false
+	}
+
+	// Gordon addresses, such as //example.com/<id>, or /<id>
+	if id, err := uuid.FromString(strings.TrimPrefix(u.Path, "/")); err == nil && (u.Host != "" || strings.HasPrefix(u.Path, "/")) {
+		return types.PageRef{Page: id, Server: u.Host}, true
+	}
+
+	// Relative links to other Markdown documents
+	if u.Host == "" && u.Path != "" && !strings.HasPrefix(u.Path, "/") {
+		switch strings.ToLower(path.Ext(u.Path)) {
+		case ".md", ".markdown":
+			return types.PageRef{Page: i.ID(path.Join(path.Dir(from), u.Path))}, true
+		}
+	}
+
+	return types.PageRef{}, false
+}
+
+// cleanPath returns path in a consistent form, so that the same document
+// always gets the same ID
+func cleanPath(p string) string {
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(p)), "./")
+}
+
+var frontMatterFence = []byte("---")
+
+// frontMatter splits doc into its front matter, where it has any, and body
+func frontMatter(doc []byte) (fm FrontMatter, body string, err error) {
+	first, rest, _ := bytes.Cut(doc, []byte("\n"))
+	if !bytes.Equal(bytes.TrimSpace(first), frontMatterFence) {
+		return fm, string(doc), nil
+	}
+
+	for off := 0; off < len(rest); {
+		line, _, _ := bytes.Cut(rest[off:], []byte("\n"))
+
+		if bytes.Equal(bytes.TrimSpace(line), frontMatterFence) {
+			dec := yaml.NewDecoder(bytes.NewReader(rest[:off]))
+			dec.KnownFields(true)
+
+			err = dec.Decode(&fm)
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+
+			body = string(rest[min(off+len(line)+1, len(rest)):])
+
+			return
+		}
+
+		off += len(line) + 1
+	}
+
+	return fm, "", errors.New("front matter is never closed")
+}
@@ -0,0 +1,243 @@
+package markdown
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jspc/gordon/markup"
+	"github.com/jspc/gordon/types"
+)
+
+const testDoc = `---
+author: jspc
+published: 2024-05-01T12:00:00Z
+tags: [gordon, docs]
+labels:
+  status: draft
+---
+# The Gordon Protocol
+
+Gordon is a *protocol* for serving
+documentation, over [DTLS](https://en.wikipedia.org/wiki/Datagram_Transport_Layer_Security).
+
+## Pages
+
+Pages are defined in [mint](./mint.md), which is described in
+[the mint docs][mint] too. See __also__ ` + "`types.Page`" + `.
+
+[mint]: mint.md
+
+### Sections
+
+- one
+- two
+  carries on
+1. three
+
+> quoted
+> text
+>
+> more
+
+` + "```go" + `
+page := new(types.Page)
+` + "```" + `
+
+Requests
+--------
+
+![a diagram](diagram.png) of <//example.com/208b43d9-a95d-476d-ba3b-3b64fda2507b>
+
+    indented code
+
+## Pages
+
+Again, with snake_case and 2 * 3.
+`
+
+func TestImport(t *testing.T) {
+	p, err := Import("docs/index.md", strings.NewReader(testDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Title != "The Gordon Protocol" {
+		t.Errorf("unexpected title %q", p.Title)
+	}
+
+	if p.Meta.ID != (Importer{}).ID("docs/index.md") || p.Meta.ID != (Importer{}).ID("./docs/../docs/index.md") {
+		t.Errorf("expected stable ID, received %s", p.Meta.ID)
+	}
+
+	if p.Meta.Author != "jspc" || !p.Meta.Published.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) || p.Meta.Revision == "" {
+		t.Errorf("unexpected metadata %#v", p.Meta)
+	}
+
+	if !reflect.DeepEqual(p.Tags, []string{"gordon", "docs"}) || p.Labels["status"] != "draft" {
+		t.Errorf("unexpected tags %v and labels %v", p.Tags, p.Labels)
+	}
+
+	expectLinks := []types.PageRef{
+		{Page: (Importer{}).ID("docs/mint.md")},
+		{Page: uuid.Must(uuid.FromString("208b43d9-a95d-476d-ba3b-3b64fda2507b")), Server: "example.com"},
+	}
+
+	if !reflect.DeepEqual(p.Links, expectLinks) {
+		t.Errorf("expected links %v, received %v", expectLinks, p.Links)
+	}
+
+	expectSections := []types.Section{
+		{Title: "Introduction", Body: "Gordon is a *protocol* for serving documentation, over DTLS (https://en.wikipedia.org/wiki/Datagram_Transport_Layer_Security).\n"},
+		{Title: "Pages", Body: "Pages are defined in mint [l:0], which is described in the mint docs [l:0] too. See *also* `types.Page`.\n\n" +
+			"# Sections\n\n" +
+			"* one\n* two carries on\n* three\n\n" +
+			"> quoted text\n> more\n\n" +
+			"```go\npage := new(types.Page)\n```\n"},
+		{Title: "Requests", Body: "a diagram of [l:1]\n\n```\nindented code\n```\n"},
+		{Title: "Pages (2)", Body: "Again, with snake_case and 2 * 3.\n"},
+	}
+
+	if !reflect.DeepEqual(p.Sections, expectSections) {
+		t.Errorf("expected\n%#v\nreceived\n%#v", expectSections, p.Sections)
+	}
+
+	err = markup.ValidatePage(*p)
+	if err != nil {
+		t.Errorf("expected valid markup, received %v", err)
+	}
+}
+
+func TestImport_Titles(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		doc           string
+		expectTitle   string
+		expectSection string
+	}{
+		{"From the first heading", "# Title\n\n## One\n", "Title", "One"},
+		{"From front matter", "---\ntitle: Front\n---\n# Title\n", "Front", "Title"},
+		{"From front matter, duplicated in a heading", "---\ntitle: Title\n---\n# Title\n\n# One\n", "Title", "One"},
+		{"From the file name", "## One\n", "some-doc", "One"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := Import("some-doc.md", strings.NewReader(test.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if p.Title != test.expectTitle {
+				t.Errorf("expected title %q, received %q", test.expectTitle, p.Title)
+			}
+
+			if len(p.Sections) == 0 || p.Sections[0].Title != test.expectSection {
+				t.Errorf("expected first section %q, received %#v", test.expectSection, p.Sections)
+			}
+		})
+	}
+}
+
+func TestImport_Escapes(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		doc    string
+		expect markup.Block
+	}{
+		{"Escaped emphasis", `\*star\*`, &markup.Paragraph{Lines: [][]markup.Node{{&markup.Text{Value: "*star*"}}}}},
+		{"Escaped emphasis within emphasis", `*a \* b*`, &markup.Paragraph{Lines: [][]markup.Node{{&markup.Emphasis{Children: []markup.Node{&markup.Text{Value: "a * b"}}}}}}},
+		{"Escaped heading", `\# not a heading`, &markup.Paragraph{Lines: [][]markup.Node{{&markup.Text{Value: "# not a heading"}}}}},
+		{"Escaped list", `\* not a list`, &markup.Paragraph{Lines: [][]markup.Node{{&markup.Text{Value: "* not a list"}}}}},
+		{"Escaped quote", `\> not a quote`, &markup.Paragraph{Lines: [][]markup.Node{{&markup.Text{Value: "> not a quote"}}}}},
+		{"Index links in text", "See [l:3] here", &markup.Paragraph{Lines: [][]markup.Node{{&markup.Text{Value: "See [l:3] here"}}}}},
+		{"Index links in code", "Use `[l:3]` in bodies", &markup.Paragraph{Lines: [][]markup.Node{{
+			&markup.Text{Value: "Use "}, &markup.Code{Value: "[l:3]"}, &markup.Text{Value: " in bodies"},
+		}}}},
+		{"Code containing backticks", "a `` x`y `` b", &markup.Paragraph{Lines: [][]markup.Node{{&markup.Text{Value: "a x`y b"}}}}},
+		{"Code blocks containing fences", "~~~\n```\ncode\n```\n~~~", &markup.CodeBlock{Code: "```\ncode\n```"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := Import("doc.md", strings.NewReader(test.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(p.Sections) != 1 || len(p.Links) != 0 {
+				t.Fatalf("unexpected sections %#v, and links %v", p.Sections, p.Links)
+			}
+
+			blocks, err := markup.Parse(p.Sections[0].Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(blocks, []markup.Block{test.expect}) {
+				t.Errorf("expected\n%#v\nreceived\n%#v, from %q", test.expect, blocks, p.Sections[0].Body)
+			}
+		})
+	}
+}
+
+func TestImport_Errors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		doc  string
+	}{
+		{"Unclosed front matter", "---\ntitle: oops\n"},
+		{"Unknown front matter", "---\ntitel: oops\n---\n"},
+		{"Invalid ID", "---\nid: not-a-uuid\n---\n"},
+		{"Invalid page", "---\ntags: [\"\"]\n---\n"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Import("doc.md", strings.NewReader(test.doc))
+			if err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	_, err := Importer{}.ImportFile("testdata/missing.md")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, received %v", err)
+	}
+}
+
+func TestImporter(t *testing.T) {
+	ns := uuid.Must(uuid.NewV4())
+	id := uuid.Must(uuid.NewV4())
+
+	path := filepath.Join(t.TempDir(), "doc.md")
+
+	err := os.WriteFile(path, []byte("---\nid: "+id.String()+"\n---\n[home](https://example.com) and [other](other.md)"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i := Importer{
+		Namespace: ns,
+		Link: func(from, dest string) (types.PageRef, bool) {
+			return types.PageRef{Page: uuid.NewV5(ns, dest)}, dest != "other.md"
+		},
+	}
+
+	p, err := i.ImportFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Meta.ID != id {
+		t.Errorf("expected declared ID %s, received %s", id, p.Meta.ID)
+	}
+
+	if i.ID("doc.md") == (Importer{}).ID("doc.md") {
+		t.Error("expected IDs to depend on Namespace")
+	}
+
+	if body := p.Sections[0].Body; body != "home [l:0] and other (other.md)\n" {
+		t.Errorf("unexpected body %q", body)
+	}
+}
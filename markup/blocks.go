@@ -0,0 +1,178 @@
+package markup
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/jspc/gordon/internal/linktoken"
+)
+
+// MaxHeadingLevel is the deepest a Heading may be
+const MaxHeadingLevel = 3
+
+// A Block is a block level element of a parsed section body, made up of one
+// or more lines
+type Block interface {
+	block()
+}
+
+// A Heading is a line of text starting with between one and
+// MaxHeadingLevel #s
+type Heading struct {
+	Level   int
+	Content []Node
+}
+
+func (*Heading) block() {}
+
+// A Paragraph is one or more consecutive lines of text
+type Paragraph struct {
+	Lines [][]Node
+}
+
+func (*Paragraph) block() {}
+
+// A List is one or more consecutive lines starting with *
+type List struct {
+	Items [][]Node
+}
+
+func (*List) block() {}
+
+// A Quote is one or more consecutive lines starting with >
+type Quote struct {
+	Lines [][]Node
+}
+
+func (*Quote) block() {}
+
+// A CodeBlock is the text between two lines of ```, or of as many more
+// backticks as it takes for the code not to close the block early
+type CodeBlock struct {
+	// Lang is the language of the code, where given
+	Lang string
+
+	// Code is the text of the block, exactly as written, without a
+	// trailing newline
+	Code string
+}
+
+func (*CodeBlock) block() {}
+
+// Emphasis is text between two *s
+type Emphasis struct {
+	Children []Node
+}
+
+func (*Emphasis) node() {}
+
+// Code is text between two `s
+type Code struct {
+	Value string
+}
+
+func (*Code) node() {}
+
+// SyntaxError describes a body which isn't well formed
+type SyntaxError struct {
+	// Line is the line of the body with the problem, starting from 1
+	Line int
+
+	// Message describes the problem
+	Message string
+}
+
+// Error fulfills the error interface
+func (e SyntaxError) Error() string {
+	return "line " + strconv.Itoa(e.Line) + ": " + e.Message
+}
+
+// Parse parses body into Blocks.
+//
+// Parse is forgiving, and returns every Block it can make sense of even
+// where body isn't well formed; problems are returned as SyntaxErrors,
+// joined with errors.Join
+func Parse(body string) (blocks []Block, err error) {
+	blocks, errs := parse(body)
+
+	return blocks, errors.Join(errs...)
+}
+
+// Serialize turns blocks back into a body, in canonical form: each Block
+// ends with a newline, and is separated from the next by a blank line
+func Serialize(blocks []Block) string {
+	sb := new(strings.Builder)
+
+	for i, b := range blocks {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+
+		switch b := b.(type) {
+		case *Heading:
+			sb.WriteString(strings.Repeat("#", b.Level) + " " + String(b.Content) + "\n")
+
+		case *Paragraph:
+			writeLines(sb, "", b.Lines)
+
+		case *List:
+			writeLines(sb, "* ", b.Items)
+
+		case *Quote:
+			writeLines(sb, "> ", b.Lines)
+
+		case *CodeBlock:
+			fence := CodeFence(b.Code)
+
+			sb.WriteString(fence + b.Lang + "\n")
+
+			if b.Code != "" {
+				sb.WriteString(b.Code + "\n")
+			}
+
+			sb.WriteString(fence + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// CodeFence returns the line a code block of code is opened and closed
+// with: ```, or one more backtick than the longest line of code made only
+// of backticks, so that no line of code closes the block early
+func CodeFence(code string) string {
+	fence := linktoken.Fence
+
+	for _, l := range strings.Split(code, "\n") {
+		l = strings.TrimSpace(l)
+		if len(l) >= len(fence) && strings.Trim(l, "`") == "" {
+			fence = l + "`"
+		}
+	}
+
+	return fence
+}
+
+func writeLines(sb *strings.Builder, prefix string, lines [][]Node) {
+	for _, l := range lines {
+		s := String(l)
+
+		// Paragraph text which looks like the start of another
+		// kind of line is escaped
+		if _, ok := first(l).(*Text); ok && prefix == "" && (headingLevel(s) > 0 || strings.HasPrefix(s, ">") || strings.HasPrefix(s, "* ")) {
+			s = `\` + s
+		}
+
+		sb.WriteString(prefix + s + "\n")
+	}
+}
+
+// first returns the first of nodes, or nil where there are none
+func first(nodes []Node) Node {
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	return nodes[0]
+}
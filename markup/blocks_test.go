@@ -0,0 +1,166 @@
+package markup
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jspc/gordon/types"
+)
+
+func TestParse(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		body      string
+		expect    []Block
+		expectErr bool
+	}{
+		{"Empty body", "", nil, false},
+		{"Paragraphs", "one\ntwo\n\nthree\n", []Block{
+			&Paragraph{Lines: [][]Node{{&Text{"one"}}, {&Text{"two"}}}},
+			&Paragraph{Lines: [][]Node{{&Text{"three"}}}},
+		}, false},
+		{"Headings", "# One\n### Three\n#### Four\n#Five", []Block{
+			&Heading{Level: 1, Content: []Node{&Text{"One"}}},
+			&Heading{Level: 3, Content: []Node{&Text{"Three"}}},
+			&Paragraph{Lines: [][]Node{{&Text{"#### Four"}}, {&Text{"#Five"}}}},
+		}, false},
+		{"Empty heading", "# ", []Block{&Heading{Level: 1}}, true},
+		{"Lists and quotes", "* a\n* b\n> c\n>d\ne", []Block{
+			&List{Items: [][]Node{{&Text{"a"}}, {&Text{"b"}}}},
+			&Quote{Lines: [][]Node{{&Text{"c"}}, {&Text{"d"}}}},
+			&Paragraph{Lines: [][]Node{{&Text{"e"}}}},
+		}, false},
+		{"Code blocks", "```mint\ntype Page {\n\n  *string* Title = 0; [l:9]\n}\n```\ntext", []Block{
+			&CodeBlock{Lang: "mint", Code: "type Page {\n\n  *string* Title = 0; [l:9]\n}"},
+			&Paragraph{Lines: [][]Node{{&Text{"text"}}}},
+		}, false},
+		{"Unclosed code blocks", "```\ncode\n", []Block{&CodeBlock{Code: "code"}}, true},
+		{"Windows line endings", "* a\r\n* b\r\n", []Block{
+			&List{Items: [][]Node{{&Text{"a"}}, {&Text{"b"}}}},
+		}, false},
+		{"Inline markup", "a *b [l:0]* `c *d*` [l:1] ** e*", []Block{
+			&Paragraph{Lines: [][]Node{{
+				&Text{"a "},
+				&Emphasis{Children: []Node{&Text{"b "}, &Link{Index: 0}}},
+				&Text{" "},
+				&Code{Value: "c *d*"},
+				&Text{" "},
+				&Link{Index: 1},
+				&Text{" *"},
+				&Emphasis{Children: []Node{&Text{" e"}}},
+			}}},
+		}, false},
+		{"Escapes", "\\# not a heading\n\\* not a list\n\\> not a quote\n\\```\n\\*a\\* \\`b\\` *c \\* d* \\[l:0] \\\\[l:1] \\a", []Block{
+			&Paragraph{Lines: [][]Node{
+				{&Text{"# not a heading"}},
+				{&Text{"* not a list"}},
+				{&Text{"> not a quote"}},
+				{&Text{"```"}},
+				{
+					&Text{"*a* `b` "},
+					&Emphasis{Children: []Node{&Text{"c * d"}}},
+					&Text{" [l:0] \\"},
+					&Link{Index: 1},
+					&Text{" \\a"},
+				},
+			}},
+		}, false},
+		{"Longer fences", "````\n```\n````", []Block{&CodeBlock{Code: "```"}}, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			blocks, err := Parse(test.body)
+			if (err != nil) != test.expectErr {
+				t.Errorf("expected error %v, received %v", test.expectErr, err)
+			}
+
+			var se SyntaxError
+			if err != nil && !errors.As(err, &se) {
+				t.Errorf("expected SyntaxError, received %#v", err)
+			}
+
+			if !reflect.DeepEqual(test.expect, blocks) {
+				t.Errorf("expected\n%#v\nreceived\n%#v", test.expect, blocks)
+			}
+
+			// Serializing should produce a canonical body which
+			// parses to the same blocks
+			canonical := Serialize(blocks)
+
+			again, _ := Parse(canonical)
+			if !reflect.DeepEqual(test.expect, again) {
+				t.Errorf("expected %q to parse to the same blocks, received\n%#v", canonical, again)
+			}
+
+			if !reflect.DeepEqual(Serialize(again), canonical) {
+				t.Errorf("expected %q to be canonical, received %q", canonical, Serialize(again))
+			}
+		})
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	body := "#  Title\n\n\n* one\n*   two\n>quote\n```go\nx := 1\n```\nsome *text*"
+	expect := "# Title\n\n* one\n* two\n\n> quote\n\n```go\nx := 1\n```\n\nsome *text*\n"
+
+	blocks, err := Parse(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if received := Serialize(blocks); received != expect {
+		t.Errorf("expected %q, received %q", expect, received)
+	}
+}
+
+func TestSerialize_Escapes(t *testing.T) {
+	for _, test := range []struct {
+		block  Block
+		expect string
+	}{
+		{&Paragraph{Lines: [][]Node{{&Text{"2 * 3, `a` and [l:0]"}}}}, "2 * 3, \\`a` and \\[l:0]\n"},
+		{&Paragraph{Lines: [][]Node{{&Text{"a *b* c \\"}, &Link{Index: 0}}}}, "a \\*b* c \\\\[l:0]\n"},
+		{&Paragraph{Lines: [][]Node{{&Text{"# a"}}, {&Text{"#a"}}, {&Text{"> a"}}, {&Text{"* a"}}}}, "\\# a\n#a\n\\> a\n\\* a\n"},
+		{&List{Items: [][]Node{{&Text{"# a"}}}}, "* # a\n"},
+		{&CodeBlock{Code: "```\n ````"}, "`````\n```\n ````\n`````\n"},
+	} {
+		received := Serialize([]Block{test.block})
+		if received != test.expect {
+			t.Errorf("expected %q, received %q", test.expect, received)
+		}
+	}
+}
+
+func TestValidatePage(t *testing.T) {
+	p := types.Page{
+		Links: []types.PageRef{{Page: uuid.Must(uuid.NewV4())}},
+		Sections: []types.Section{
+			{Title: "Fine", Body: "# Hi\n\n* *see [l:0]*"},
+			{Title: "Dangling", Body: "> [l:1]"},
+			{Title: "Broken", Body: "#\n# \n```\n"},
+		},
+	}
+
+	err := ValidatePage(p)
+
+	var errs types.FieldErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected types.FieldErrors, received %#v", err)
+	}
+
+	fields := make([]string, len(errs))
+	for i, fe := range errs {
+		fields[i] = fe.Field
+	}
+
+	expect := []string{"Sections[1].Body", "Sections[2].Body", "Sections[2].Body"}
+	if !reflect.DeepEqual(expect, fields) {
+		t.Errorf("expected errors for %v, received %v", expect, errs)
+	}
+
+	err = ValidatePage(types.Page{Sections: p.Sections[:1], Links: p.Links})
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+// Package markup parses the bodies of gordon page sections, so that
+// clients, renderers, and importers needn't each write their own parser.
+//
+// Section bodies are largely plain text, with a little line based markup
+// in the spirit of gemtext. Each line is one of:
+//
+//	# Heading           a heading, of between one and three #s
+//	* Item              an item in a list
+//	> Quote             a line of a quote
+//	```lang             the start or end of a code block
+//	Anything else       a line of a paragraph
+//
+// Consecutive list items, quote lines, and paragraph lines form a single
+// list, quote, or paragraph, which end at a blank line or a line of any
+// other kind. Lines between the opening and closing ``` of a code block are
+// left exactly as they are, and may optionally name the language of the
+// code after the opening ```. Code blocks may also be opened with more than
+// three backticks, and are then only closed by a line of as many, so that
+// code may itself contain a line of ```.
+//
+// Within headings, list items, quotes, and paragraphs:
+//
+//	*emphasis*          emphasises text, which may contain index links
+//	`code`              is code, left exactly as it is
+//	[l:0]               is an index link, to element 0 of the page's Links
+//	\*                  is a *, or any of \ ` [ # >, as text
+//
+// Markup which doesn't fit these rules, such as an unmatched *, is text.
+// Escapes work everywhere but code, and so a line starting \# is part of a
+// paragraph, rather than a heading. Backslashes before anything else are
+// text too.
+//
+// Index links within code, whether code blocks or `code`, and escaped index
+// links, such as \[l:0], are text too, and so are left alone by
+// Page.Validate, Resolve, Rewrite, and DedupeLinks.
+//
+// Parse turns a body into a list of Blocks; Serialize turns Blocks back into
+// a body, in canonical form. Validate and ValidatePage report bodies which
+// aren't well formed, or which link past the end of Links
+package markup
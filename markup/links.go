@@ -0,0 +1,307 @@
+package markup
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jspc/gordon/internal/linktoken"
+	"github.com/jspc/gordon/types"
+)
+
+// A Node is an inline element of a parsed section body
+type Node interface {
+	node()
+}
+
+// Text is a run of plain text. Text parsed from a body has had its escapes
+// removed, and String escapes it again wherever it would otherwise be
+// mistaken for markup
+type Text struct {
+	Value string
+}
+
+func (*Text) node() {}
+
+// A Link is an index link, such as [l:0], which refers to an element of
+// the Links of the page the body belongs to
+type Link struct {
+	// Index is the element of Links this Link refers to
+	Index int
+
+	// Ref is the PageRef at Index, set by Resolve. It is nil until then,
+	// and where Index is past the end of Links
+	Ref *types.PageRef
+}
+
+func (*Link) node() {}
+
+// DanglingLinksError is returned by Resolve where index links refer past the
+// end of Links
+type DanglingLinksError struct {
+	// Indices are the indices which don't resolve, in the order they're
+	// first seen
+	Indices []int
+
+	// Links is the number of Links the body was resolved against
+	Links int
+}
+
+// Error fulfills the error interface
+func (e DanglingLinksError) Error() string {
+	return fmt.Sprintf("index links %v point past the end of Links, which has %d elements", e.Indices, e.Links)
+}
+
+// Tokenize splits body into Text and Link nodes, in order. Index links
+// within code, and escaped index links, are text. Text is left exactly as
+// written, markup and escapes included, such that joining the Text and
+// index links back together gives body. The Links it returns aren't
+// resolved; see Resolve
+func Tokenize(body string) []Node {
+	return nodes(body, linktoken.Find(body))
+}
+
+// tokenize splits s, which has no code in it, into Text and Link nodes,
+// removing escapes from the Text
+func tokenize(s string) []Node {
+	nodes := nodes(s, linktoken.FindText(s))
+
+	for _, n := range nodes {
+		if t, ok := n.(*Text); ok {
+			t.Value = unescape(t.Value)
+		}
+	}
+
+	return nodes
+}
+
+// unescape returns s without the backslashes which escape characters
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	sb := new(strings.Builder)
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && linktoken.IsEscapable(s[i+1]) {
+			i++
+		}
+
+		sb.WriteByte(s[i])
+	}
+
+	return sb.String()
+}
+
+// nodes splits s into Text nodes, and a Link node for each of tokens
+func nodes(s string, tokens []linktoken.Token) (nodes []Node) {
+	var last int
+
+	for _, tok := range tokens {
+		if tok.Start > last {
+			nodes = append(nodes, &Text{Value: s[last:tok.Start]})
+		}
+
+		nodes = append(nodes, &Link{Index: tok.Index})
+		last = tok.End
+	}
+
+	if last < len(s) {
+		nodes = append(nodes, &Text{Value: s[last:]})
+	}
+
+	return
+}
+
+// Resolve sets the Ref of each Link in nodes to the element of links it
+// refers to. Links which point past the end of links are left with a nil
+// Ref, and reported in a DanglingLinksError
+func Resolve(nodes []Node, links []types.PageRef) error {
+	var dangling []int
+
+	walk(nodes, func(l *Link) {
+		if l.Index >= len(links) {
+			l.Ref = nil
+
+			if !slices.Contains(dangling, l.Index) {
+				dangling = append(dangling, l.Index)
+			}
+
+			return
+		}
+
+		l.Ref = &links[l.Index]
+	})
+
+	if len(dangling) > 0 {
+		return DanglingLinksError{Indices: dangling, Links: len(links)}
+	}
+
+	return nil
+}
+
+// String turns nodes back into markup, escaping Text wherever it would
+// otherwise be mistaken for markup
+func String(nodes []Node) string {
+	l := new(line)
+	l.write(nodes)
+
+	return l.String()
+}
+
+// Escape returns s with every character which could be read as markup
+// escaped, for writing text into bodies without first working out what
+// would be mistaken for markup and what wouldn't. Serialize removes any
+// escapes which aren't needed
+func Escape(s string) string {
+	sb := new(strings.Builder)
+
+	for i := 0; i < len(s); i++ {
+		if linktoken.IsEscapable(s[i]) {
+			sb.WriteByte('\\')
+		}
+
+		sb.WriteByte(s[i])
+	}
+
+	return sb.String()
+}
+
+// Rewrite returns body with each index link rewritten to the index returned
+// by fn. Where fn returns a negative index, the link is removed altogether.
+// Everything else in body is left exactly as it is
+func Rewrite(body string, fn func(idx int) int) string {
+	sb := new(strings.Builder)
+
+	for _, n := range Tokenize(body) {
+		switch n := n.(type) {
+		case *Text:
+			sb.WriteString(n.Value)
+
+		case *Link:
+			if idx := fn(n.Index); idx >= 0 {
+				sb.WriteString(linktoken.Format(idx))
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+// DedupeLinks removes repeated elements of p.Links, keeping the first of
+// each, and rewrites the index links in each section of p to match
+func DedupeLinks(p *types.Page) {
+	var (
+		links   = make([]types.PageRef, 0, len(p.Links))
+		mapping = make([]int, len(p.Links))
+	)
+
+	for i, ref := range p.Links {
+		idx := slices.Index(links, ref)
+		if idx < 0 {
+			idx = len(links)
+			links = append(links, ref)
+		}
+
+		mapping[i] = idx
+	}
+
+	for i := range p.Sections {
+		p.Sections[i].Body = Rewrite(p.Sections[i].Body, func(idx int) int {
+			// Dangling links are left as they are, since there's
+			// nothing better to point them at
+			if idx >= len(mapping) {
+				return idx
+			}
+
+			return mapping[idx]
+		})
+	}
+
+	p.Links = links
+}
+
+// walk calls fn for each Link in nodes, including those within Emphasis
+func walk(nodes []Node, fn func(*Link)) {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *Link:
+			fn(n)
+
+		case *Emphasis:
+			walk(n.Children, fn)
+		}
+	}
+}
+
+// line is a line of markup being written, which remembers which of its bytes
+// came from Text, and so may need escaping
+type line struct {
+	buf  []byte
+	text []bool
+}
+
+func (l *line) write(nodes []Node) {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *Text:
+			l.append(n.Value, true)
+
+		case *Link:
+			l.append(linktoken.Format(n.Index), false)
+
+		case *Emphasis:
+			l.append("*", false)
+			l.write(n.Children)
+			l.append("*", false)
+
+		case *Code:
+			l.append("`"+n.Value+"`", false)
+		}
+	}
+}
+
+func (l *line) append(s string, text bool) {
+	l.buf = append(l.buf, s...)
+
+	for range len(s) {
+		l.text = append(l.text, text)
+	}
+}
+
+// String returns the line, with each byte of Text which would otherwise be
+// read as markup escaped: delimiters with another of the same delimiter
+// after them, the [ of anything which looks like an index link, and
+// backslashes before anything a backslash escapes
+func (l *line) String() string {
+	sb := new(strings.Builder)
+	s := string(l.buf)
+
+	for i := 0; i < len(s); i++ {
+		if l.text[i] && l.escape(s, i) {
+			sb.WriteByte('\\')
+		}
+
+		sb.WriteByte(s[i])
+	}
+
+	return sb.String()
+}
+
+func (l *line) escape(s string, i int) bool {
+	switch s[i] {
+	case '*', '`':
+		return strings.IndexByte(s[i+1:], s[i]) >= 0
+
+	case '[':
+		tokens := linktoken.FindText(s[i:])
+
+		return len(tokens) > 0 && tokens[0].Start == 0
+
+	case '\\':
+		return i+1 < len(s) && linktoken.IsEscapable(s[i+1])
+	}
+
+	return false
+}
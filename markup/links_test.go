@@ -0,0 +1,137 @@
+package markup
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jspc/gordon/types"
+)
+
+func TestTokenize(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		body   string
+		expect []Node
+	}{
+		{"Empty body", "", nil},
+		{"Plain text", "hello", []Node{&Text{"hello"}}},
+		{"Only a link", "[l:0]", []Node{&Link{Index: 0}}},
+		{"Text and links", "see [l:1] and [l:0].", []Node{
+			&Text{"see "}, &Link{Index: 1}, &Text{" and "}, &Link{Index: 0}, &Text{"."},
+		}},
+		{"Adjacent links", "[l:0][l:1]", []Node{&Link{Index: 0}, &Link{Index: 1}}},
+		{"Malformed links are text", "[l:] [l:a] [l:99999999999999999999]", []Node{&Text{"[l:] [l:a] [l:99999999999999999999]"}}},
+		{"Links in code are text", "`[l:0]` [l:1]\n```\n[l:2]\n```\n* `[l:3]` *[l:4]*", []Node{
+			&Text{"`[l:0]` "}, &Link{Index: 1}, &Text{"\n```\n[l:2]\n```\n* `[l:3]` *"}, &Link{Index: 4}, &Text{"*"},
+		}},
+		{"Escaped links are text", "\\[l:0] \\\\[l:1]", []Node{&Text{"\\[l:0] \\\\"}, &Link{Index: 1}}},
+		{"Code within emphasis is text", "*a `[l:0]` b*", []Node{&Text{"*a `"}, &Link{Index: 0}, &Text{"` b*"}}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			nodes := Tokenize(test.body)
+			if !reflect.DeepEqual(test.expect, nodes) {
+				t.Errorf("expected %#v, received %#v", test.expect, nodes)
+			}
+
+			if s := Rewrite(test.body, func(idx int) int { return idx }); s != test.body {
+				t.Errorf("expected %q to round trip, received %q", test.body, s)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	links := []types.PageRef{
+		{Page: uuid.Must(uuid.NewV4())},
+		{Page: uuid.Must(uuid.NewV4())},
+	}
+
+	nodes := Tokenize("[l:1] [l:3] [l:0] [l:2] [l:3]")
+
+	err := Resolve(nodes, links)
+
+	var dle DanglingLinksError
+	if !errors.As(err, &dle) {
+		t.Fatalf("expected DanglingLinksError, received %v", err)
+	}
+
+	if !reflect.DeepEqual(dle.Indices, []int{3, 2}) || dle.Links != 2 {
+		t.Errorf("unexpected error %#v", dle)
+	}
+
+	for i, expect := range []*types.PageRef{&links[1], nil, &links[0], nil, nil} {
+		ref := nodes[i*2].(*Link).Ref
+		if ref != expect {
+			t.Errorf("%d: expected %v, received %v", i, expect, ref)
+		}
+	}
+
+	err = Resolve(Tokenize("[l:0] [l:1]"), links)
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	body := "a [l:0] b [l:1] c [l:2]"
+	expect := "a [l:2] b  c [l:0]"
+
+	received := Rewrite(body, func(idx int) int {
+		return []int{2, -1, 0}[idx]
+	})
+
+	if received != expect {
+		t.Errorf("expected %q, received %q", expect, received)
+	}
+}
+
+func TestRewrite_Code(t *testing.T) {
+	body := "`[l:0]` [l:0]\n\n```\n[l:0]\n```\n"
+	expect := "`[l:0]` [l:1]\n\n```\n[l:0]\n```\n"
+
+	received := Rewrite(body, func(idx int) int {
+		return idx + 1
+	})
+
+	if received != expect {
+		t.Errorf("expected %q, received %q", expect, received)
+	}
+
+	// Validation and rewriting must agree on what is, and isn't, a link
+	err := Validate(body, nil)
+	if !errors.As(err, new(DanglingLinksError)) {
+		t.Errorf("expected DanglingLinksError, received %v", err)
+	}
+
+	err = Validate("`[l:0]`\n\n```\n[l:0]\n```\n", nil)
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestDedupeLinks(t *testing.T) {
+	a := types.PageRef{Page: uuid.Must(uuid.NewV4())}
+	b := types.PageRef{Page: uuid.Must(uuid.NewV4())}
+
+	p := &types.Page{
+		Links: []types.PageRef{a, b, a, b, a},
+		Sections: []types.Section{
+			{Title: "One", Body: "[l:0] [l:1] [l:2]"},
+			{Title: "Two", Body: "[l:3] [l:4] [l:5]"},
+		},
+	}
+
+	DedupeLinks(p)
+
+	if !reflect.DeepEqual(p.Links, []types.PageRef{a, b}) {
+		t.Errorf("unexpected links %v", p.Links)
+	}
+
+	for i, expect := range []string{"[l:0] [l:1] [l:0]", "[l:1] [l:0] [l:5]"} {
+		if p.Sections[i].Body != expect {
+			t.Errorf("%d: expected %q, received %q", i, expect, p.Sections[i].Body)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package markup
+
+import (
+	"strings"
+
+	"github.com/jspc/gordon/internal/linktoken"
+)
+
+type parser struct {
+	lines  []string
+	blocks []Block
+	errs   []error
+
+	// open is the List, Quote, or Paragraph further lines of the same
+	// kind are added to, or nil where the next line starts a new Block
+	open Block
+}
+
+func (p *parser) parse() ([]Block, []error) {
+	for i := 0; i < len(p.lines); i++ {
+		line := p.line(i)
+
+		switch {
+		case linktoken.OpeningFence(line) != "":
+			i = p.code(i)
+
+		case strings.TrimSpace(line) == "":
+			p.open = nil
+
+		case headingLevel(line) > 0:
+			p.heading(i)
+
+		case strings.HasPrefix(line, "* "):
+			l, ok := p.open.(*List)
+			if !ok {
+				l = new(List)
+				p.add(l)
+			}
+
+			l.Items = append(l.Items, parseInline(strings.TrimLeft(line[2:], " ")))
+			p.open = l
+
+		case strings.HasPrefix(line, ">"):
+			q, ok := p.open.(*Quote)
+			if !ok {
+				q = new(Quote)
+				p.add(q)
+			}
+
+			q.Lines = append(q.Lines, parseInline(strings.TrimLeft(line[1:], " ")))
+			p.open = q
+
+		default:
+			para, ok := p.open.(*Paragraph)
+			if !ok {
+				para = new(Paragraph)
+				p.add(para)
+			}
+
+			para.Lines = append(para.Lines, parseInline(line))
+			p.open = para
+		}
+	}
+
+	return p.blocks, p.errs
+}
+
+// line returns line i, without any carriage return left over from
+// bodies written with \r\n line endings
+func (p *parser) line(i int) string {
+	return strings.TrimSuffix(p.lines[i], "\r")
+}
+
+func (p *parser) add(b Block) {
+	p.blocks = append(p.blocks, b)
+	p.open = nil
+}
+
+func (p *parser) error(i int, msg string) {
+	p.errs = append(p.errs, SyntaxError{Line: i + 1, Message: msg})
+}
+
+func (p *parser) heading(i int) {
+	line := p.line(i)
+	level := headingLevel(line)
+
+	content := strings.TrimSpace(line[level:])
+	if content == "" {
+		p.error(i, "heading is empty")
+	}
+
+	p.add(&Heading{Level: level, Content: parseInline(content)})
+}
+
+// code adds the code block opened on line i, returning the line which
+// closes it
+func (p *parser) code(i int) int {
+	fence := linktoken.OpeningFence(p.line(i))
+
+	cb := &CodeBlock{Lang: strings.TrimSpace(p.line(i)[len(fence):])}
+	p.add(cb)
+
+	var lines []string
+
+	for j := i + 1; j < len(p.lines); j++ {
+		line := p.line(j)
+		if strings.TrimSpace(line) == fence {
+			cb.Code = strings.Join(lines, "\n")
+
+			return j
+		}
+
+		lines = append(lines, line)
+	}
+
+	p.error(i, "code block is never closed")
+
+	// Bodies ending in a newline split into a final, empty, line which
+	// isn't part of the code
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	cb.Code = strings.Join(lines, "\n")
+
+	return len(p.lines)
+}
+
+// headingLevel returns the level of the heading on line, or 0 where line
+// isn't a heading
+func headingLevel(line string) int {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > MaxHeadingLevel || !strings.HasPrefix(line[level:], " ") {
+		return 0
+	}
+
+	return level
+}
+
+// parseInline parses the emphasis, code, and index links within a single
+// line of text
+func parseInline(s string) (nodes []Node) {
+	var last int
+
+	for _, span := range linktoken.Spans(s) {
+		nodes = append(nodes, tokenize(s[last:span.Start])...)
+
+		switch span.Delim {
+		case '*':
+			nodes = append(nodes, &Emphasis{Children: tokenize(s[span.Start+1 : span.End])})
+
+		case '`':
+			nodes = append(nodes, &Code{Value: s[span.Start+1 : span.End]})
+		}
+
+		last = span.End + 1
+	}
+
+	return append(nodes, tokenize(s[last:])...)
+}
+
+// parse parses body, returning each problem with it separately
+func parse(body string) ([]Block, []error) {
+	p := parser{lines: strings.Split(body, "\n")}
+
+	return p.parse()
+}
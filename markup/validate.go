@@ -0,0 +1,82 @@
+package markup
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/jspc/gordon/types"
+)
+
+// ResolveBlocks resolves the index links in blocks against links, as
+// Resolve does for individual nodes
+func ResolveBlocks(blocks []Block, links []types.PageRef) error {
+	var nodes []Node
+	for _, b := range blocks {
+		nodes = append(nodes, inlines(b)...)
+	}
+
+	return Resolve(nodes, links)
+}
+
+// Validate returns an error where body isn't well formed, or where it has
+// index links which point past the end of links
+func Validate(body string, links []types.PageRef) error {
+	return errors.Join(validate(body, links)...)
+}
+
+// ValidatePage validates the body of each section of p, as Validate does,
+// returning problems as a types.FieldErrors addressed to each section's Body
+func ValidatePage(p types.Page) error {
+	var errs types.FieldErrors
+
+	for i, s := range p.Sections {
+		for _, err := range validate(s.Body, p.Links) {
+			errs = append(errs, types.FieldError{
+				Field:   "Sections[" + strconv.Itoa(i) + "].Body",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+func validate(body string, links []types.PageRef) (errs []error) {
+	blocks, errs := parse(body)
+
+	err := ResolveBlocks(blocks, links)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return
+}
+
+// inlines returns every inline Node within b
+func inlines(b Block) (nodes []Node) {
+	switch b := b.(type) {
+	case *Heading:
+		return b.Content
+
+	case *Paragraph:
+		for _, l := range b.Lines {
+			nodes = append(nodes, l...)
+		}
+
+	case *List:
+		for _, l := range b.Items {
+			nodes = append(nodes, l...)
+		}
+
+	case *Quote:
+		for _, l := range b.Lines {
+			nodes = append(nodes, l...)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,364 @@
+package gordon
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jspc/gordon/types"
+)
+
+var (
+	// DurationBuckets are the upper bounds, in seconds, of the buckets
+	// PrometheusMetrics sorts handler latencies and pool waits into
+	DurationBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+	// SizeBuckets are the upper bounds, in bytes, of the buckets
+	// PrometheusMetrics sorts response sizes into
+	SizeBuckets = []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216}
+)
+
+// Metrics records what a Listener is up to, for monitoring.
+//
+// Methods are called from many goroutines at once, and so implementations
+// must be safe for concurrent use. They're also called in the middle of
+// serving requests, and so should be quick
+type Metrics interface {
+	// RequestServed is called once a request has been answered, with the
+	// status of the page sent, the time the Handler took to answer it, and
+	// the size of the response. Requests which errored without a response
+	// are recorded with a status of types.StatusError and a size of zero
+	RequestServed(verb types.Verb, status types.Status, duration time.Duration, size int)
+
+	// HandshakeFailed is called when a client fails its DTLS handshake
+	HandshakeFailed()
+
+	// UnmarshalFailed is called when a request can't be unmarshalled
+	UnmarshalFailed()
+
+	// ConnectionDropped is called when a connection is dropped for
+	// exceeding the Listener's per-address limits, with either
+	// ErrRateLimited or ErrTooManyConnections
+	ConnectionDropped(reason error)
+
+	// PoolWait is called with how long each connection waited for one of
+	// the Listener's MaxConnections to become free
+	PoolWait(d time.Duration)
+
+	// InFlight is called with 1 when the Listener starts handling a
+	// connection, and -1 when it finishes
+	InFlight(delta int64)
+}
+
+// nopMetrics is the Metrics every Listener starts with, and records nothing
+type nopMetrics struct{}
+
+func (nopMetrics) RequestServed(types.Verb, types.Status, time.Duration, int) {}
+func (nopMetrics) HandshakeFailed()                                           {}
+func (nopMetrics) UnmarshalFailed()                                           {}
+func (nopMetrics) ConnectionDropped(error)                                    {}
+func (nopMetrics) PoolWait(time.Duration)                                     {}
+func (nopMetrics) InFlight(int64)                                             {}
+
+// PrometheusMetrics is a Metrics which keeps everything it records in memory,
+// and serves it over HTTP in the Prometheus text exposition format.
+//
+//	l.Metrics = gordon.NewPrometheusMetrics()
+//	l.MetricsAddress = "127.0.0.1:9100"
+//
+// or, to serve metrics alongside something else, a PrometheusMetrics may
+// be served by any http.Server.
+//
+// A PrometheusMetrics should be created with NewPrometheusMetrics
+type PrometheusMetrics struct {
+	mu sync.Mutex
+
+	requests        map[[2]string]uint64
+	durations       map[string]*histogram
+	sizes           *histogram
+	poolWaits       *histogram
+	handshakeFailed uint64
+	unmarshalFailed uint64
+	droppedRate     uint64
+	droppedQuota    uint64
+	inFlight        int64
+}
+
+// metricsReadHeaderTimeout is how long the metrics server served on a
+// Listener's MetricsAddress waits for request headers
+const metricsReadHeaderTimeout = time.Second * 5
+
+// serveMetrics serves the Listener's Metrics on its MetricsAddress, where
+// set, returning the server so it can be closed with the Listener
+func (l *Listener) serveMetrics() (*http.Server, error) {
+	if l.MetricsAddress == "" {
+		return nil, nil
+	}
+
+	h, ok := l.Metrics.(http.Handler)
+	if !ok {
+		return nil, ErrMetricsNotServable
+	}
+
+	ln, err := net.Listen("tcp", l.MetricsAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", h)
+
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: metricsReadHeaderTimeout,
+	}
+
+	go func() {
+		err := srv.Serve(ln)
+		if !errors.Is(err, http.ErrServerClosed) {
+			l.Logger.Error("Metrics server stopped", slog.Any("error", err))
+		}
+	}()
+
+	return srv, nil
+}
+
+// closeServer closes srv, where there is one
+func closeServer(srv *http.Server) error {
+	if srv == nil {
+		return nil
+	}
+
+	return srv.Close()
+}
+
+// NewPrometheusMetrics returns an empty PrometheusMetrics
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{
+		requests:  make(map[[2]string]uint64),
+		durations: make(map[string]*histogram),
+		sizes:     newHistogram(SizeBuckets),
+		poolWaits: newHistogram(DurationBuckets),
+	}
+}
+
+// RequestServed fulfills the Metrics interface
+func (m *PrometheusMetrics) RequestServed(verb types.Verb, status types.Status, duration time.Duration, size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v := verbToString(verb)
+
+	m.requests[[2]string{v, statusToString(status)}]++
+
+	h, ok := m.durations[v]
+	if !ok {
+		h = newHistogram(DurationBuckets)
+		m.durations[v] = h
+	}
+
+	h.observe(duration.Seconds())
+	m.sizes.observe(float64(size))
+}
+
+// HandshakeFailed fulfills the Metrics interface
+func (m *PrometheusMetrics) HandshakeFailed() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.handshakeFailed++
+}
+
+// UnmarshalFailed fulfills the Metrics interface
+func (m *PrometheusMetrics) UnmarshalFailed() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.unmarshalFailed++
+}
+
+// ConnectionDropped fulfills the Metrics interface
+func (m *PrometheusMetrics) ConnectionDropped(reason error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if errors.Is(reason, ErrRateLimited) {
+		m.droppedRate++
+	} else {
+		m.droppedQuota++
+	}
+}
+
+// PoolWait fulfills the Metrics interface
+func (m *PrometheusMetrics) PoolWait(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.poolWaits.observe(d.Seconds())
+}
+
+// InFlight fulfills the Metrics interface
+func (m *PrometheusMetrics) InFlight(delta int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.inFlight += delta
+}
+
+// ServeHTTP serves everything recorded so far in the Prometheus text
+// exposition format
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	//#nosec: G104
+	m.WriteTo(w)
+}
+
+// WriteTo writes everything recorded so far to w in the Prometheus text
+// exposition format
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (n int64, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sb := new(strings.Builder)
+
+	header(sb, "gordon_requests_total", "counter", "Requests answered, by verb and status")
+
+	keys := make([][2]string, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+
+	slices.SortFunc(keys, func(a, b [2]string) int {
+		return strings.Compare(a[0]+" "+a[1], b[0]+" "+b[1])
+	})
+
+	for _, k := range keys {
+		fmt.Fprintf(sb, "gordon_requests_total{verb=%q,status=%q} %d\n", k[0], k[1], m.requests[k])
+	}
+
+	header(sb, "gordon_request_duration_seconds", "histogram", "Time taken by the Handler to answer requests, by verb")
+
+	verbs := make([]string, 0, len(m.durations))
+	for v := range m.durations {
+		verbs = append(verbs, v)
+	}
+
+	slices.Sort(verbs)
+
+	for _, v := range verbs {
+		m.durations[v].write(sb, "gordon_request_duration_seconds", fmt.Sprintf("verb=%q", v))
+	}
+
+	header(sb, "gordon_response_size_bytes", "histogram", "Size of responses")
+	m.sizes.write(sb, "gordon_response_size_bytes", "")
+
+	header(sb, "gordon_pool_wait_seconds", "histogram", "Time connections waited for a free slot in the connection pool")
+	m.poolWaits.write(sb, "gordon_pool_wait_seconds", "")
+
+	header(sb, "gordon_handshake_failures_total", "counter", "DTLS handshakes which failed")
+	fmt.Fprintf(sb, "gordon_handshake_failures_total %d\n", m.handshakeFailed)
+
+	header(sb, "gordon_unmarshal_failures_total", "counter", "Requests which could not be unmarshalled")
+	fmt.Fprintf(sb, "gordon_unmarshal_failures_total %d\n", m.unmarshalFailed)
+
+	header(sb, "gordon_connections_dropped_total", "counter", "Connections dropped for exceeding per-address limits, by reason")
+	fmt.Fprintf(sb, "gordon_connections_dropped_total{reason=\"rate_limited\"} %d\n", m.droppedRate)
+	fmt.Fprintf(sb, "gordon_connections_dropped_total{reason=\"quota_exceeded\"} %d\n", m.droppedQuota)
+
+	header(sb, "gordon_in_flight", "gauge", "Connections currently being handled")
+	fmt.Fprintf(sb, "gordon_in_flight %d\n", m.inFlight)
+
+	written, err := io.WriteString(w, sb.String())
+
+	return int64(written), err
+}
+
+func header(sb *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// histogram counts observations into buckets, in the same way as a
+// Prometheus histogram
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)),
+	}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+
+	h.sum += v
+	h.count++
+}
+
+// write writes h to sb as the series name, with any extra labels
+func (h *histogram) write(sb *strings.Builder, name, labels string) {
+	sep := ""
+	if labels != "" {
+		sep = ","
+	}
+
+	for i, bound := range h.bounds {
+		fmt.Fprintf(sb, "%s_bucket{%s%sle=%q} %d\n", name, labels, sep, formatFloat(bound), h.counts[i])
+	}
+
+	fmt.Fprintf(sb, "%s_bucket{%s%sle=\"+Inf\"} %d\n", name, labels, sep, h.count)
+
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+
+	fmt.Fprintf(sb, "%s_sum%s %s\n", name, labels, formatFloat(h.sum))
+	fmt.Fprintf(sb, "%s_count%s %d\n", name, labels, h.count)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func statusToString(s types.Status) string {
+	switch s {
+	case types.StatusOK:
+		return "ok"
+	case types.StatusError:
+		return "error"
+	case types.StatusNotFound:
+		return "not_found"
+	case types.StatusForbidden:
+		return "forbidden"
+	case types.StatusBadRequest:
+		return "bad_request"
+	case types.StatusRedirect:
+		return "redirect"
+	case types.StatusNotModified:
+		return "not_modified"
+	case types.StatusTooLarge:
+		return "too_large"
+	case types.StatusUnavailable:
+		return "unavailable"
+	}
+
+	return "unknown"
+}
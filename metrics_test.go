@@ -0,0 +1,157 @@
+package gordon
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jspc/gordon/client"
+	"github.com/jspc/gordon/types"
+	"github.com/pion/dtls/v2/pkg/crypto/selfsign"
+)
+
+func TestPrometheusMetrics_WriteTo(t *testing.T) {
+	m := NewPrometheusMetrics()
+
+	m.RequestServed(types.VerbRead, types.StatusOK, time.Millisecond*20, 2000)
+	m.RequestServed(types.VerbRead, types.StatusError, time.Millisecond*2, 100)
+	m.RequestServed(types.VerbCreate, types.StatusOK, time.Second*20, 100)
+	m.HandshakeFailed()
+	m.UnmarshalFailed()
+	m.UnmarshalFailed()
+	m.ConnectionDropped(ErrRateLimited)
+	m.ConnectionDropped(ErrTooManyConnections)
+	m.PoolWait(time.Millisecond)
+	m.InFlight(1)
+	m.InFlight(1)
+	m.InFlight(-1)
+
+	sb := new(strings.Builder)
+
+	_, err := m.WriteTo(sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expect := range []string{
+		`gordon_requests_total{verb="create",status="ok"} 1`,
+		`gordon_requests_total{verb="read",status="error"} 1`,
+		`gordon_requests_total{verb="read",status="ok"} 1`,
+		`gordon_request_duration_seconds_bucket{verb="read",le="0.005"} 1`,
+		`gordon_request_duration_seconds_bucket{verb="read",le="0.025"} 2`,
+		`gordon_request_duration_seconds_bucket{verb="create",le="10"} 0`,
+		`gordon_request_duration_seconds_bucket{verb="create",le="+Inf"} 1`,
+		`gordon_request_duration_seconds_count{verb="read"} 2`,
+		`gordon_response_size_bytes_bucket{le="256"} 2`,
+		`gordon_response_size_bytes_bucket{le="4096"} 3`,
+		`gordon_response_size_bytes_sum 2200`,
+		`gordon_pool_wait_seconds_count 1`,
+		`gordon_handshake_failures_total 1`,
+		`gordon_unmarshal_failures_total 2`,
+		`gordon_connections_dropped_total{reason="rate_limited"} 1`,
+		`gordon_connections_dropped_total{reason="quota_exceeded"} 1`,
+		`gordon_in_flight 1`,
+		`# TYPE gordon_request_duration_seconds histogram`,
+	} {
+		if !strings.Contains(sb.String(), expect+"\n") {
+			t.Errorf("expected output to contain %q\n%s", expect, sb.String())
+		}
+	}
+}
+
+func TestListener_ListenAndServe_Metrics(t *testing.T) {
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	m := NewPrometheusMetrics()
+
+	l, _ := NewListener(new(dummyHandler), cert)
+	l.Metrics = m
+
+	defer l.Close()
+
+	startListener(t, &l, "localhost:4478")
+
+	addr, _ := client.ParseAddress("//localhost:4478/")
+
+	_, err := client.DoRequest(types.VerbRead, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Requests are recorded once the response has been sent, and so
+	// may not have been by the time we've received it
+	var body string
+	for i := 0; i < 100; i++ {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+		body = w.Body.String()
+		if strings.Contains(body, "gordon_in_flight 0\n") {
+			break
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	for _, expect := range []string{
+		`gordon_requests_total{verb="read",status="ok"} 1`,
+		`gordon_pool_wait_seconds_count 1`,
+		`gordon_in_flight 0`,
+	} {
+		if !strings.Contains(body, expect+"\n") {
+			t.Errorf("expected output to contain %q\n%s", expect, body)
+		}
+	}
+}
+
+func TestListener_ListenAndServe_MetricsAddress(t *testing.T) {
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	l, _ := NewListener(new(dummyHandler), cert)
+	l.Metrics = NewPrometheusMetrics()
+	l.MetricsAddress = "127.0.0.1:4484"
+
+	startListener(t, &l, "localhost:4485")
+
+	resp, err := http.Get("http://127.0.0.1:4484/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "gordon_in_flight") {
+		t.Errorf("unexpected response %d\n%s", resp.StatusCode, body)
+	}
+
+	err = l.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = http.Get("http://127.0.0.1:4484/metrics")
+	if err == nil {
+		t.Error("expected metrics server to be closed with the Listener")
+	}
+}
+
+func TestListener_ListenAndServe_MetricsNotServable(t *testing.T) {
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	l, _ := NewListener(new(dummyHandler), cert)
+	l.MetricsAddress = "127.0.0.1:4486"
+
+	err := l.ListenAndServe("localhost:4487")
+	if !errors.Is(err, ErrMetricsNotServable) {
+		t.Errorf("expected ErrMetricsNotServable, received %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package gordon
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	"github.com/jspc/gordon/types"
+)
+
+// A Middleware wraps a ContextHandler in some other behaviour, such as
+// logging, authentication, or validation, returning a ContextHandler which
+// does both.
+//
+// Middlewares may answer a request themselves, without ever calling the
+// ContextHandler they wrap
+type Middleware func(ContextHandler) ContextHandler
+
+// Chain wraps h in each Middleware in m, such that the first Middleware
+// is the outermost, and so sees each request first and each response last.
+//
+//	h := gordon.Chain(gordon.AdaptHandler(someServer{}),
+//		gordon.Recover,
+//		gordon.AllowVerbs(types.VerbRead),
+//	)
+func Chain(h ContextHandler, m ...Middleware) ContextHandler {
+	for i := len(m) - 1; i >= 0; i-- {
+		h = m[i](h)
+	}
+
+	return h
+}
+
+// Recover is a Middleware which turns panics in the ContextHandler it wraps
+// into a PanicError.
+//
+// Timeout runs the ContextHandler it wraps in a separate goroutine, and a
+// panic can only be recovered in the goroutine it happens in; where both are
+// used, Recover must come after (and so inside) Timeout.
+//
+// Every Listener applies this Middleware, inside its own Timeout, so that a
+// panicking Handler is answered with an error page rather than crashing
+// the server
+func Recover(next ContextHandler) ContextHandler {
+	return HandlerFunc(func(ctx context.Context, req *types.Request) (resp *types.Page, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				resp, err = nil, PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+
+		return next.ServeContext(ctx, req)
+	})
+}
+
+// Logging returns a Middleware which logs a line for every request to
+// logger, including how long the request took and whether it errored.
+//
+// Every Listener logs requests in the same way to its own Logger, once the
+// response has been sent, along with the size of that response
+func Logging(logger *slog.Logger) Middleware {
+	return func(next ContextHandler) ContextHandler {
+		return HandlerFunc(func(ctx context.Context, req *types.Request) (resp *types.Page, err error) {
+			start := time.Now()
+
+			resp, err = next.ServeContext(ctx, req)
+
+			logRequest(ctx, logger, accessLogEntry{
+				req:      req,
+				resp:     resp,
+				err:      err,
+				duration: time.Since(start),
+				size:     -1,
+			}, DefaultAccessLogFields)
+
+			return
+		})
+	}
+}
+
+// Timeout returns a Middleware which stops waiting for the ContextHandler it
+// wraps after d, answering the request with an error page instead.
+//
+// The context passed to the ContextHandler is cancelled at the same time;
+// ContextHandlers which ignore it will carry on running in the background
+// until they return. A d of zero or less sets no deadline, but the
+// ContextHandler is still abandoned if the request context is cancelled
+// some other way.
+//
+// Every Listener applies this Middleware with its RequestTimeout
+func Timeout(d time.Duration) Middleware {
+	return func(next ContextHandler) ContextHandler {
+		return HandlerFunc(func(ctx context.Context, req *types.Request) (resp *types.Page, err error) {
+			var cancel context.CancelFunc
+			if d > 0 {
+				ctx, cancel = context.WithTimeout(ctx, d)
+			} else {
+				ctx, cancel = context.WithCancel(ctx)
+			}
+
+			defer cancel()
+
+			type result struct {
+				resp *types.Page
+				err  error
+			}
+
+			// buffered so that ContextHandlers which ignore ctx can
+			// still return, and be garbage collected, long after we've
+			// stopped waiting for them
+			c := make(chan result, 1)
+			go func() {
+				resp, err := next.ServeContext(ctx, req)
+				c <- result{resp, err}
+			}()
+
+			select {
+			case r := <-c:
+				resp, err = r.resp, r.err
+
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return errorPage(req.ID, types.StatusUnavailable, "Request Timed Out"), nil
+			}
+
+			return
+		})
+	}
+}
+
+// AllowVerbs returns a Middleware which answers requests for any Verb not in
+// verbs with an error page, without calling the ContextHandler it wraps
+func AllowVerbs(verbs ...types.Verb) Middleware {
+	return func(next ContextHandler) ContextHandler {
+		return HandlerFunc(func(ctx context.Context, req *types.Request) (*types.Page, error) {
+			if !slices.Contains(verbs, req.Verb) {
+				return errorPage(req.ID, types.StatusBadRequest, "Verb Not Supported"), nil
+			}
+
+			return next.ServeContext(ctx, req)
+		})
+	}
+}
+
+// RequireArgs returns a Middleware which answers requests for Verb v with an
+// error page, without calling the ContextHandler it wraps, unless every one
+// of args is set in the request's Args.
+//
+// Requests for any other Verb are passed through untouched
+func RequireArgs(v types.Verb, args ...string) Middleware {
+	return func(next ContextHandler) ContextHandler {
+		return HandlerFunc(func(ctx context.Context, req *types.Request) (*types.Page, error) {
+			if req.Verb == v {
+				for _, arg := range args {
+					if _, ok := req.Args[arg]; !ok {
+						return errorPage(req.ID, types.StatusBadRequest, "Missing Argument "+arg), nil
+					}
+				}
+			}
+
+			return next.ServeContext(ctx, req)
+		})
+	}
+}
+
+// ConditionalRead is a Middleware which answers Reads for pages the client
+// already has an up to date copy of with a NotModified page, rather than
+// the whole page, where the client says which copy it has with the
+// types.ArgIfNoneMatch or types.ArgIfModifiedSince Args.
+//
+// Pages are compared by Revision where both the page and the request have
+// one, and by Published date otherwise, to the second. Pages without either
+// are always sent in full.
+//
+// Every Listener applies this Middleware
+func ConditionalRead(next ContextHandler) ContextHandler {
+	return HandlerFunc(func(ctx context.Context, req *types.Request) (resp *types.Page, err error) {
+		resp, err = next.ServeContext(ctx, req)
+		if err != nil || resp == nil || req.Verb != types.VerbRead || resp.Status != types.StatusOK {
+			return
+		}
+
+		if notModified(req, resp.Meta) {
+			return notModifiedPage(resp.Meta), nil
+		}
+
+		return
+	})
+}
+
+// notModified returns whether the copy of a page the client which made req
+// already has is the same as the page described by meta
+func notModified(req *types.Request, meta types.Metadata) bool {
+	if revision, ok := req.Args[types.ArgIfNoneMatch]; ok && meta.Revision != "" {
+		return revision == meta.Revision
+	}
+
+	since, ok := req.Args[types.ArgIfModifiedSince]
+	if !ok || meta.Published.IsZero() {
+		return false
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, since)
+	if err != nil {
+		return false
+	}
+
+	return !meta.Published.Truncate(time.Second).After(t.Truncate(time.Second))
+}
@@ -0,0 +1,238 @@
+package gordon
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jspc/gordon/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type panickyHandler struct{}
+
+func (panickyHandler) Serve(*types.Request) (*types.Page, error) {
+	panic("oh no")
+}
+
+func orderingMiddleware(name string, order *[]string) Middleware {
+	return func(next ContextHandler) ContextHandler {
+		return HandlerFunc(func(ctx context.Context, req *types.Request) (*types.Page, error) {
+			*order = append(*order, name)
+
+			return next.ServeContext(ctx, req)
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	order := make([]string, 0)
+
+	h := Chain(AdaptHandler(dummyHandler{}),
+		orderingMiddleware("first", &order),
+		orderingMiddleware("second", &order),
+		orderingMiddleware("third", &order),
+	)
+
+	_, err := h.ServeContext(context.Background(), new(types.Request))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []string{"first", "second", "third"}
+	if len(order) != len(expect) {
+		t.Fatalf("expected %v, received %v", expect, order)
+	}
+
+	for i := range expect {
+		if expect[i] != order[i] {
+			t.Errorf("expected %v, received %v", expect, order)
+		}
+	}
+}
+
+func TestRecover(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		handler     Handler
+		expectPanic bool
+	}{
+		{"Panics become PanicErrors", panickyHandler{}, true},
+		{"Errors are passed through", dummyHandler{err: true}, false},
+		{"Pages are passed through", dummyHandler{}, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Recover(AdaptHandler(test.handler)).ServeContext(context.Background(), new(types.Request))
+
+			var pe PanicError
+			if errors.As(err, &pe) != test.expectPanic {
+				t.Errorf("expected PanicError: %v, received %#v", test.expectPanic, err)
+			}
+
+			if test.expectPanic && pe.Value != "oh no" {
+				t.Errorf("unexpected panic value %#v", pe.Value)
+			}
+		})
+	}
+}
+
+func TestLogging(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	addr, _ := net.ResolveUDPAddr("udp", "127.0.0.1:1234")
+	ctx := withPeer(context.Background(), Peer{RemoteAddr: addr})
+
+	h := Logging(slog.New(NewZapHandler(zap.New(core))))(AdaptHandler(dummyHandler{}))
+
+	_, err := h.ServeContext(ctx, &types.Request{Verb: types.VerbRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if logs.Len() != 1 {
+		t.Fatalf("expected 1 log line, received %d", logs.Len())
+	}
+
+	fields := logs.All()[0].ContextMap()
+	for k, v := range map[string]any{
+		"verb":           "read",
+		"remote_address": "127.0.0.1:1234",
+		"is_error":       false,
+	} {
+		if fields[k] != v {
+			t.Errorf("%s: expected %#v, received %#v", k, v, fields[k])
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		handler     ContextHandler
+		timeout     time.Duration
+		expectTitle string
+	}{
+		{"Fast handlers return their own page", AdaptHandler(dummyHandler{}), time.Second, "A Test Page"},
+		{"Slow handlers return an error page", slowHandler{}, time.Millisecond * 10, "Request Timed Out"},
+		{"Slow handlers which ignore context return an error page", AdaptHandler(stubbornHandler{}), time.Millisecond * 10, "Request Timed Out"},
+		{"Zero timeouts do not time out", slowHandler{}, 0, "A Test Page"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := Timeout(test.timeout)(test.handler).ServeContext(context.Background(), new(types.Request))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if p.Title != test.expectTitle {
+				t.Errorf("expected %q, received %q", test.expectTitle, p.Title)
+			}
+		})
+	}
+}
+
+func TestAllowVerbs(t *testing.T) {
+	h := AllowVerbs(types.VerbRead)(AdaptHandler(dummyHandler{}))
+
+	for _, test := range []struct {
+		name         string
+		verb         types.Verb
+		expectStatus types.Status
+	}{
+		{"Allowed verbs are passed through", types.VerbRead, types.StatusOK},
+		{"Other verbs are rejected", types.VerbDelete, types.StatusBadRequest},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := h.ServeContext(context.Background(), &types.Request{Verb: test.verb})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if p.Status != test.expectStatus {
+				t.Errorf("expected %v, received %v", test.expectStatus, p.Status)
+			}
+		})
+	}
+}
+
+func TestRequireArgs(t *testing.T) {
+	h := RequireArgs(types.VerbCreate, "Body")(AdaptHandler(dummyHandler{}))
+
+	for _, test := range []struct {
+		name         string
+		req          *types.Request
+		expectStatus types.Status
+	}{
+		{"Requests with args are passed through", &types.Request{Verb: types.VerbCreate, Args: map[string]string{"Body": "hello"}}, types.StatusOK},
+		{"Requests without args are rejected", &types.Request{Verb: types.VerbCreate}, types.StatusBadRequest},
+		{"Requests for other verbs are passed through", &types.Request{Verb: types.VerbRead}, types.StatusOK},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := h.ServeContext(context.Background(), test.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if p.Status != test.expectStatus {
+				t.Errorf("expected %v, received %v", test.expectStatus, p.Status)
+			}
+		})
+	}
+}
+
+func TestConditionalRead(t *testing.T) {
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	page := func(revision string, published time.Time) ContextHandler {
+		return HandlerFunc(func(context.Context, *types.Request) (*types.Page, error) {
+			return &types.Page{
+				Title:  "A Test Page",
+				Status: types.StatusOK,
+				Meta: types.Metadata{
+					Revision:  revision,
+					Published: published,
+				},
+			}, nil
+		})
+	}
+
+	read := func(args map[string]string) *types.Request {
+		return &types.Request{Verb: types.VerbRead, Args: args}
+	}
+
+	for _, test := range []struct {
+		name         string
+		h            ContextHandler
+		req          *types.Request
+		expectStatus types.Status
+	}{
+		{"Unconditional reads are passed through", page("r1", published), read(nil), types.StatusOK},
+		{"Matching revisions are not modified", page("r1", published), read(map[string]string{types.ArgIfNoneMatch: "r1"}), types.StatusNotModified},
+		{"Differing revisions are sent in full", page("r2", published), read(map[string]string{types.ArgIfNoneMatch: "r1"}), types.StatusOK},
+		{"Revisions take precedence over dates", page("r2", published), read(map[string]string{types.ArgIfNoneMatch: "r1", types.ArgIfModifiedSince: published.Format(time.RFC3339)}), types.StatusOK},
+		{"Pages without revisions fall back to dates", page("", published), read(map[string]string{types.ArgIfNoneMatch: "r1", types.ArgIfModifiedSince: published.Format(time.RFC3339)}), types.StatusNotModified},
+		{"Pages published since are sent in full", page("", published), read(map[string]string{types.ArgIfModifiedSince: published.Add(-time.Hour).Format(time.RFC3339)}), types.StatusOK},
+		{"Sub-second differences are ignored", page("", published.Add(time.Millisecond)), read(map[string]string{types.ArgIfModifiedSince: published.Format(time.RFC3339)}), types.StatusNotModified},
+		{"Malformed dates are ignored", page("", published), read(map[string]string{types.ArgIfModifiedSince: "yesterday"}), types.StatusOK},
+		{"Pages without dates are sent in full", page("", time.Time{}), read(map[string]string{types.ArgIfModifiedSince: published.Format(time.RFC3339)}), types.StatusOK},
+		{"Other verbs are passed through", page("r1", published), &types.Request{Verb: types.VerbUpdate, Args: map[string]string{types.ArgIfNoneMatch: "r1"}}, types.StatusOK},
+		{"Errors are passed through", AdaptHandler(dummyHandler{err: true}), read(map[string]string{types.ArgIfNoneMatch: "r1"}), 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p, _ := ConditionalRead(test.h).ServeContext(context.Background(), test.req)
+
+			var status types.Status
+			if p != nil {
+				status = p.Status
+			}
+
+			if status != test.expectStatus {
+				t.Errorf("expected %v, received %v", test.expectStatus, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package gordon
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jspc/gordon/types"
+)
+
+// A ServeMux routes requests to ContextHandlers based on the Verb and
+// Page ID of a request.
+//
+// For each request, a ServeMux tries, in order:
+//
+//  1. A ContextHandler registered for both the Verb and ID, via HandlePage
+//  2. A ContextHandler registered for the Verb, via Handle
+//  3. The fallback ContextHandler, via HandleFallback
+//
+// Where none of those exist, the request is answered with an error page;
+// "Page Not Found" where something is registered for the Verb, and "Verb
+// Not Supported" otherwise.
+//
+// A ServeMux is safe to use from multiple goroutines, and should be
+// created with NewServeMux
+type ServeMux struct {
+	mu       sync.RWMutex
+	verbs    map[types.Verb]ContextHandler
+	pages    map[types.Verb]map[uuid.UUID]ContextHandler
+	fallback ContextHandler
+}
+
+// NewServeMux returns an empty ServeMux
+func NewServeMux() *ServeMux {
+	return &ServeMux{
+		verbs: make(map[types.Verb]ContextHandler),
+		pages: make(map[types.Verb]map[uuid.UUID]ContextHandler),
+	}
+}
+
+// Handle registers h for every request with the Verb v, replacing any
+// ContextHandler previously registered for v
+func (m *ServeMux) Handle(v types.Verb, h ContextHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.verbs[v] = h
+}
+
+// HandlePage registers h for requests with the Verb v for the Page id,
+// replacing any ContextHandler previously registered for both.
+//
+// uuid.Nil may be used to register a ContextHandler for requests which
+// don't specify a Page, such as for an index
+func (m *ServeMux) HandlePage(v types.Verb, id uuid.UUID, h ContextHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.pages[v]; !ok {
+		m.pages[v] = make(map[uuid.UUID]ContextHandler)
+	}
+
+	m.pages[v][id] = h
+}
+
+// HandleFallback registers h for any request which matches nothing else
+func (m *ServeMux) HandleFallback(h ContextHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.fallback = h
+}
+
+// Serve fulfills the Handler interface, calling ServeContext with a
+// background context
+func (m *ServeMux) Serve(req *types.Request) (*types.Page, error) {
+	return m.ServeContext(context.Background(), req)
+}
+
+// ServeContext fulfills the ContextHandler interface, passing req to
+// whichever ContextHandler matches it
+func (m *ServeMux) ServeContext(ctx context.Context, req *types.Request) (*types.Page, error) {
+	return m.handler(req).ServeContext(ctx, req)
+}
+
+func (m *ServeMux) handler(req *types.Request) ContextHandler {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	pages, knownVerb := m.pages[req.Verb]
+	if h, ok := pages[req.ID]; ok {
+		return h
+	}
+
+	if h, ok := m.verbs[req.Verb]; ok {
+		return h
+	}
+
+	if m.fallback != nil {
+		return m.fallback
+	}
+
+	if knownVerb {
+		return errorHandler(types.StatusNotFound, "Page Not Found")
+	}
+
+	return errorHandler(types.StatusBadRequest, "Verb Not Supported")
+}
+
+// errorHandler answers every request with an error page, titled title
+func errorHandler(status types.Status, title string) ContextHandler {
+	return HandlerFunc(func(_ context.Context, req *types.Request) (*types.Page, error) {
+		return errorPage(req.ID, status, title), nil
+	})
+}
@@ -0,0 +1,60 @@
+package gordon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jspc/gordon/types"
+)
+
+func titledHandler(title string) ContextHandler {
+	return HandlerFunc(func(context.Context, *types.Request) (*types.Page, error) {
+		return &types.Page{Title: title, Status: types.StatusOK}, nil
+	})
+}
+
+func TestServeMux_ServeContext(t *testing.T) {
+	id := uuid.Must(uuid.NewV4())
+
+	mux := NewServeMux()
+	mux.Handle(types.VerbRead, titledHandler("read"))
+	mux.HandlePage(types.VerbRead, id, titledHandler("read page"))
+	mux.HandlePage(types.VerbRead, uuid.Nil, titledHandler("index"))
+	mux.HandlePage(types.VerbUpdate, id, titledHandler("update page"))
+
+	withFallback := NewServeMux()
+	withFallback.Handle(types.VerbRead, titledHandler("read"))
+	withFallback.HandleFallback(titledHandler("fallback"))
+
+	for _, test := range []struct {
+		name         string
+		mux          *ServeMux
+		req          *types.Request
+		expectTitle  string
+		expectStatus types.Status
+	}{
+		{"Page handlers take precedence", mux, &types.Request{Verb: types.VerbRead, ID: id}, "read page", types.StatusOK},
+		{"Nil IDs route to index handlers", mux, &types.Request{Verb: types.VerbRead}, "index", types.StatusOK},
+		{"Verb handlers catch other pages", mux, &types.Request{Verb: types.VerbRead, ID: uuid.Must(uuid.NewV4())}, "read", types.StatusOK},
+		{"Pages with no handler are not found", mux, &types.Request{Verb: types.VerbUpdate, ID: uuid.Must(uuid.NewV4())}, "Page Not Found", types.StatusNotFound},
+		{"Verbs with no handler are not supported", mux, &types.Request{Verb: types.VerbDelete, ID: id}, "Verb Not Supported", types.StatusBadRequest},
+		{"Fallbacks catch everything else", withFallback, &types.Request{Verb: types.VerbDelete, ID: id}, "fallback", types.StatusOK},
+		{"Fallbacks do not replace verb handlers", withFallback, &types.Request{Verb: types.VerbRead, ID: id}, "read", types.StatusOK},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := test.mux.ServeContext(context.Background(), test.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if p.Title != test.expectTitle {
+				t.Errorf("expected %q, received %q", test.expectTitle, p.Title)
+			}
+
+			if p.Status != test.expectStatus {
+				t.Errorf("expected %v, received %v", test.expectStatus, p.Status)
+			}
+		})
+	}
+}
@@ -4,19 +4,33 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"log/slog"
 	"net"
+	"net/http"
+	"sync"
 	"time"
 
+	"github.com/gofrs/uuid/v5"
+	"github.com/jspc/gordon/frame"
+	"github.com/jspc/gordon/internal/fingerprint"
 	"github.com/jspc/gordon/types"
 	"github.com/pion/dtls/v2"
+	"github.com/pion/dtls/v2/pkg/protocol"
+	"github.com/pion/dtls/v2/pkg/protocol/recordlayer"
+	"github.com/pion/transport/v2/udp"
 	"go.uber.org/zap"
 	"golang.org/x/sync/semaphore"
 )
 
 const (
-	defaultMaxWorkers int64 = 1024
-	networkUDP              = "udp"
+	defaultMaxWorkers     int64 = 1024
+	defaultMaxRequestSize       = 1024 * 1024
+	defaultRequestTimeout       = time.Second * 10
+	defaultReadTimeout          = time.Second * 5
+	handshakeTimeout            = time.Second * 5
+	networkUDP                  = "udp"
 )
 
 // A Handler responds to Gordon Requests with either a Page or an error
@@ -27,6 +41,49 @@ type Handler interface {
 	Serve(req *types.Request) (resp *types.Page, err error)
 }
 
+// A ContextHandler responds to Gordon Requests in the same way as a
+// Handler, but is also passed a context.Context which is cancelled when
+// the request times out, when the client goes away, or when the Listener
+// is closed.
+//
+// Handlers which do anything slow, such as talking to storage, should
+// prefer this interface so that their work can be abandoned when nobody
+// is waiting for it any more
+type ContextHandler interface {
+	ServeContext(ctx context.Context, req *types.Request) (resp *types.Page, err error)
+}
+
+// HandlerFunc allows a plain function to be used as a ContextHandler
+type HandlerFunc func(ctx context.Context, req *types.Request) (resp *types.Page, err error)
+
+// ServeContext calls f(ctx, req)
+func (f HandlerFunc) ServeContext(ctx context.Context, req *types.Request) (*types.Page, error) {
+	return f(ctx, req)
+}
+
+// AdaptHandler turns a Handler into a ContextHandler, allowing existing
+// Handler implementations to be served by a Listener.
+//
+// If h already implements ContextHandler then it is returned as-is. Otherwise
+// the context is ignored; the Listener will still stop waiting for the
+// Handler once the request times out, but the Handler itself will carry
+// on until it returns
+func AdaptHandler(h Handler) ContextHandler {
+	if ch, ok := h.(ContextHandler); ok {
+		return ch
+	}
+
+	return handlerAdapter{h}
+}
+
+type handlerAdapter struct {
+	h Handler
+}
+
+func (a handlerAdapter) ServeContext(_ context.Context, req *types.Request) (*types.Page, error) {
+	return a.h.Serve(req)
+}
+
 // A Listener wraps a Handler and a TLS Certificate and does all of the
 // networky stuff
 //
@@ -34,13 +91,108 @@ type Handler interface {
 // reference to a weighted semaphore- if you try to copy it or duplicate
 // it then you'll end up with very strange behaviour
 type Listener struct {
-	handler        Handler
+	handler        ContextHandler
 	listener       net.Listener
 	listenerConfig *dtls.Config
-	logger         *zap.Logger
 	requestPool    *semaphore.Weighted
+	poolSize       int64
+	limits         *addressLimiter
+	sampler        *sampler
+
+	// mu guards listener, metricsServer, requestPool, limits, closed,
+	// and conns, which are set from ListenAndServe and read from Close
+	// and Shutdown, often in different goroutines
+	mu            *sync.Mutex
+	metricsServer *http.Server
+	closed        bool
+	conns         map[net.Conn]struct{}
+
+	// ctx is the parent of every request context, and is cancelled
+	// when the Listener is closed
+	ctx    context.Context
+	cancel context.CancelFunc
 
 	MaxConnections int64
+
+	// MaxConnectionsPerAddress is the most connections a single remote IP
+	// address may have in flight at once, so that one noisy client can't
+	// take up every one of MaxConnections. Further connections from that
+	// address are dropped, before the DTLS handshake, until some finish.
+	// A value of zero or less disables the limit
+	MaxConnectionsPerAddress int64
+
+	// RateLimit is the number of new connections per second each remote IP
+	// address may make, with bursts of up to RateBurst connections allowed.
+	// Connections over the limit are dropped before the DTLS handshake, and
+	// so cost next to nothing. A value of zero or less disables the limit
+	RateLimit float64
+
+	// RateBurst is the number of connections a remote IP address may make
+	// in quick succession before RateLimit kicks in, and is treated as 1
+	// where less than that
+	RateBurst int
+
+	// ClientAuth determines whether clients must present certificates,
+	// and whether those certificates are verified against ClientCAs.
+	// It defaults to dtls.NoClientCert, where clients aren't asked
+	// for certificates at all.
+	//
+	// Certificates clients present are made available to Handlers
+	// via PeerFromContext
+	ClientAuth dtls.ClientAuthType
+
+	// ClientCAs is the set of certificate authorities client certificates
+	// are verified against, where ClientAuth requires verification
+	ClientCAs *x509.CertPool
+
+	// MaxRequestSize is the largest request, in bytes, the Listener will
+	// accept. Larger requests are answered with an error page without
+	// being read in full. A value of zero or less disables the limit,
+	// allowing any client to make the Listener allocate up to 4GiB per
+	// request, and so should be avoided
+	MaxRequestSize int
+
+	// ReadTimeout is the longest the Listener waits for a client to send
+	// the whole of its request, after which the connection is closed.
+	// This stops clients which never finish their requests, or whose
+	// records go missing in transit, from tying up connections forever.
+	// A value of zero or less disables the timeout
+	ReadTimeout time.Duration
+
+	// Metrics records requests, failures, and the state of the connection
+	// pool, for monitoring. It defaults to recording nothing; use
+	// NewPrometheusMetrics to export metrics to Prometheus
+	Metrics Metrics
+
+	// MetricsAddress, where set, is the TCP address, such as
+	// 127.0.0.1:9100, ListenAndServe serves Metrics on, at /metrics, until
+	// the Listener is closed. Metrics must implement http.Handler, as
+	// PrometheusMetrics does
+	MetricsAddress string
+
+	// RequestTimeout is the longest a Handler may spend on a request
+	// before the client is sent an error page instead. A value of zero
+	// or less disables the timeout entirely
+	RequestTimeout time.Duration
+
+	// Logger receives access logs, at info level, and connection errors.
+	// It defaults to a production zap.Logger; use NewZapHandler to log to
+	// an existing zap.Logger instead
+	Logger *slog.Logger
+
+	// AccessLogFields are the fields included in each access log line,
+	// and default to DefaultAccessLogFields
+	AccessLogFields []AccessLogField
+
+	// AccessLogSampling logs only one in every AccessLogSampling successful
+	// requests, for busy Listeners where logging every request is too
+	// much. Requests which error are always logged. A value of 1 or less
+	// logs every request
+	AccessLogSampling int
+
+	// Tracer traces each request, and each phase of it. It defaults to
+	// tracing nothing
+	Tracer Tracer
 }
 
 // NewListener accepts a Handler and a Certificate and configures a Listener
@@ -54,21 +206,65 @@ type Listener struct {
 //
 // The default size is 1024, which may be stupidly, ridiculously, overly
 // large.
+//
+// Handlers are wrapped with AdaptHandler; to serve a ContextHandler directly
+// use NewContextListener
 func NewListener(h Handler, cert tls.Certificate) (l Listener, err error) {
+	return NewContextListener(AdaptHandler(h), cert)
+}
+
+// NewContextListener accepts a ContextHandler and a Certificate and
+// configures a Listener in the same way as NewListener.
+//
+// The certificate is fixed for the life of the Listener; to rotate
+// certificates without restarting use NewListenerWithCertificateSource.
+//
+// Each request is given a context derived from the Listener which expires
+// after RequestTimeout (which defaults to 10 seconds, and may be overwritten
+// in the same way as MaxConnections).
+//
+// Requests are limited to MaxRequestSize bytes, which defaults to 1MiB, and
+// must arrive within ReadTimeout, which defaults to 5 seconds. Both may also
+// be overwritten
+func NewContextListener(h ContextHandler, cert tls.Certificate) (l Listener, err error) {
+	return NewListenerWithCertificateSource(h, StaticCertificate(cert))
+}
+
+// NewListenerWithCertificateSource accepts a ContextHandler and a
+// CertificateSource and configures a Listener in the same way as
+// NewContextListener, except that the certificate presented to clients
+// is requested from src on every handshake
+func NewListenerWithCertificateSource(h ContextHandler, src CertificateSource) (l Listener, err error) {
+	return newListener(h, &dtls.Config{
+		GetCertificate:       src.GetCertificate,
+		ExtendedMasterSecret: dtls.RequireExtendedMasterSecret,
+	})
+}
+
+// newListener configures a Listener with the defaults every constructor
+// shares, handshaking with clients according to config
+func newListener(h ContextHandler, config *dtls.Config) (l Listener, err error) {
 	l.MaxConnections = defaultMaxWorkers
+	l.MaxRequestSize = defaultMaxRequestSize
+	l.RequestTimeout = defaultRequestTimeout
+	l.ReadTimeout = defaultReadTimeout
+	l.Metrics = nopMetrics{}
+	l.Tracer = nopTracer{}
+	l.AccessLogFields = DefaultAccessLogFields
 
 	l.handler = h
+	l.mu = new(sync.Mutex)
+	l.conns = make(map[net.Conn]struct{})
+	l.ctx, l.cancel = context.WithCancel(context.Background())
+	l.listenerConfig = config
+	l.sampler = new(sampler)
 
-	ctx := context.Background()
-	l.listenerConfig = &dtls.Config{
-		Certificates:         []tls.Certificate{cert},
-		ExtendedMasterSecret: dtls.RequireExtendedMasterSecret,
-		ConnectContextMaker: func() (context.Context, func()) {
-			return context.WithTimeout(ctx, time.Second*5)
-		},
+	logger, err := zap.NewProduction()
+	if err != nil {
+		return
 	}
 
-	l.logger, err = zap.NewProduction()
+	l.Logger = slog.New(NewZapHandler(logger))
 
 	return
 }
@@ -78,112 +274,517 @@ func NewListener(h Handler, cert tls.Certificate) (l Listener, err error) {
 // types.
 //
 // This function will propagate errors creating a DTLS listener to the
-// gordon implementation; any error in processing data, or any error returned
-// from a Handler, is logged and moved on from.
+// gordon implementation; any error in processing data, including failed
+// DTLS handshakes, is logged and moved on from.
+//
+// Errors returned from Handlers, and panics, are answered with an error
+// page. Handlers may return an ErrorPage, such as a NotFoundError, to
+// choose which; any other error is logged, and answered with a generic
+// error page.
+//
+// Once the Listener is closed, via Close or Shutdown, this function returns
+// ErrListenerClosed
 func (l *Listener) ListenAndServe(address string) (err error) {
 	addr, err := net.ResolveUDPAddr(networkUDP, address)
 	if err != nil {
 		return
 	}
 
-	l.listener, err = dtls.Listen(networkUDP, addr, l.listenerConfig)
+	l.listenerConfig.ClientAuth = l.ClientAuth
+	l.listenerConfig.ClientCAs = l.ClientCAs
+
+	listener, err := listen(addr)
 	if err != nil {
 		return
 	}
 
-	l.requestPool = semaphore.NewWeighted(l.MaxConnections)
+	metricsServer, err := l.serveMetrics()
+	if err != nil {
+		return errors.Join(err, listener.Close())
+	}
+
+	// Close or Shutdown may have been called before we got this far,
+	// in which case there's nobody left to close these listeners but us
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+
+		return errors.Join(ErrListenerClosed, listener.Close(), closeServer(metricsServer))
+	}
+
+	l.listener = listener
+	l.metricsServer = metricsServer
+	l.poolSize = l.MaxConnections
+	l.requestPool = semaphore.NewWeighted(l.poolSize)
+	l.limits = newAddressLimiter(l.RateLimit, l.RateBurst, l.MaxConnectionsPerAddress)
+	l.mu.Unlock()
 
 	for {
 		conn, err := l.listener.Accept()
 		if err != nil {
-			l.logger.Error(err.Error())
+			if l.isClosed() {
+				return ErrListenerClosed
+			}
+
+			l.Logger.Error(err.Error())
 
 			return err
 		}
 
-		err = l.requestPool.Acquire(context.Background(), 1)
+		release, err := l.limits.admit(conn.RemoteAddr())
+		if err != nil {
+			l.Metrics.ConnectionDropped(err)
+			l.Logger.Debug("Connection dropped",
+				slog.Any("error", err),
+				slog.String("RemoteAddress", conn.RemoteAddr().String()),
+			)
+
+			//#nosec: G104
+			conn.Close()
+
+			continue
+		}
+
+		start := time.Now()
+
+		err = l.requestPool.Acquire(l.ctx, 1)
+		l.Metrics.PoolWait(time.Since(start))
+
 		if err != nil {
+			release()
+
+			if l.isClosed() {
+				return errors.Join(ErrListenerClosed, conn.Close())
+			}
+
 			l.connErr(conn, err)
 
 			return errors.Join(err, l.Close())
 		}
 
-		go l.process(conn)
+		go l.process(conn, release)
+	}
+}
+
+// LimitStats returns the number of connections turned away for exceeding
+// the Listener's per-address limits
+func (l *Listener) LimitStats() LimitStats {
+	l.mu.Lock()
+	limits := l.limits
+	l.mu.Unlock()
+
+	if limits == nil {
+		return LimitStats{}
+	}
+
+	return limits.stats()
+}
+
+// listen returns a UDP listener which accepts a new connection for each
+// client which starts a DTLS handshake.
+//
+// Handshakes are left to whoever accepts each connection, rather than done
+// by the listener as dtls.Listen does, so that one client failing its
+// handshake (such as by presenting a certificate we don't trust, or by
+// rejecting ours) can't stop us accepting connections from anybody else
+func listen(addr *net.UDPAddr) (net.Listener, error) {
+	lc := udp.ListenConfig{
+		AcceptFilter: func(packet []byte) bool {
+			records, err := recordlayer.UnpackDatagram(packet)
+			if err != nil || len(records) < 1 {
+				return false
+			}
+
+			h := new(recordlayer.Header)
+			if h.Unmarshal(records[0]) != nil {
+				return false
+			}
+
+			return h.ContentType == protocol.ContentTypeHandshake
+		},
 	}
+
+	return lc.Listen(networkUDP, addr)
 }
 
-// Close the underlying UDP listener
-func (l Listener) Close() error {
-	return l.listener.Close()
+// Close the underlying UDP listener, cancel the contexts of any requests
+// still being handled, and close their connections.
+//
+// In-flight requests are abandoned; to wait for them to finish use
+// Shutdown instead
+func (l *Listener) Close() error {
+	defer l.closeConns()
+	defer l.cancel()
+
+	return l.stop()
 }
 
-func (l *Listener) process(conn net.Conn) {
+// Shutdown gracefully stops the Listener. The underlying UDP listener is
+// closed so that no new connections are accepted, and then Shutdown waits
+// for any in-flight requests to finish before returning.
+//
+// If ctx expires before every request is finished then the contexts of those
+// requests are cancelled, their connections are closed, and the error from
+// ctx is returned.
+//
+// Once Shutdown (or Close) has been called, ListenAndServe returns
+// ErrListenerClosed
+func (l *Listener) Shutdown(ctx context.Context) (err error) {
+	defer l.cancel()
+
+	err = l.stop()
+
+	l.mu.Lock()
+	pool, size := l.requestPool, l.poolSize
+	l.mu.Unlock()
+
+	// Acquiring the whole semaphore can only succeed once every
+	// request has released its share of it
+	if pool != nil {
+		err = errors.Join(err, pool.Acquire(ctx, size))
+	}
+
+	// Requests still blocked reading from, or writing to, their
+	// connections won't notice their contexts being cancelled, and so
+	// the only way to free them up is to pull the connection out from
+	// under them
+	if ctx.Err() != nil {
+		l.closeConns()
+	}
+
+	return
+}
+
+// stop marks the Listener as closed and closes the underlying UDP
+// listener, and the metrics server, where they exist and haven't already
+// been closed
+func (l *Listener) stop() (err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil
+	}
+
+	l.closed = true
+
+	if l.listener != nil {
+		err = l.listener.Close()
+	}
+
+	return errors.Join(err, closeServer(l.metricsServer))
+}
+
+// track records conn as being in use, until untrack is called, so that it
+// can be closed should the Listener be closed before conn is finished with
+func (l *Listener) track(conn net.Conn) (untrack func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.conns[conn] = struct{}{}
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		delete(l.conns, conn)
+	}
+}
+
+// closeConns closes every connection still in use
+func (l *Listener) closeConns() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for conn := range l.conns {
+		//#nosec: G104
+		conn.Close()
+	}
+}
+
+func (l *Listener) isClosed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.closed
+}
+
+// process handshakes with, and then serves, a single client. release is
+// called once the client is finished with, freeing up its share of the
+// Listener's per-address limits
+func (l *Listener) process(udpConn net.Conn, release func()) {
+	defer release()
 	defer l.requestPool.Release(1)
-	defer conn.Close()
+	defer udpConn.Close()
+	defer l.track(udpConn)()
 
-	start := time.Now()
+	l.Metrics.InFlight(1)
+	defer l.Metrics.InFlight(-1)
+
+	var err error
+
+	trace := newRequestTrace(l.Tracer)
+	defer func() {
+		trace.end(l.ctx, err)
+	}()
+
+	conn, err := l.handshake(udpConn)
+	trace.finished(SpanHandshake, trace.start, err)
 
-	data := make([]byte, 1024)
-	_, err := conn.Read(data)
 	if err != nil {
-		l.connErr(conn, err)
+		l.Metrics.HandshakeFailed()
+		l.connErr(udpConn, err)
 
 		return
 	}
 
-	buf := bytes.NewBuffer(data)
-	req := new(types.Request)
+	defer conn.Close()
+
+	unmarshalStart := time.Now()
 
-	err = req.Unmarshall(buf)
+	data, err := l.readRequest(conn)
 	if err != nil {
+		trace.finished(SpanUnmarshal, unmarshalStart, err)
 		l.connErr(conn, err)
 
+		// Let clients know why they're not getting what they
+		// asked for, rather than leaving them hanging
+		if errors.As(err, new(frame.TooLargeError)) {
+			l.respond(trace.begin(l.ctx), trace, conn, uuid.Nil, errorPage(uuid.Nil, types.StatusTooLarge, "Request Too Large"))
+		}
+
 		return
 	}
 
-	resp, err := l.handler.Serve(req)
+	req := new(types.Request)
+
+	err = req.Unmarshall(bytes.NewBuffer(data))
+	trace.finished(SpanUnmarshal, unmarshalStart, err)
+
 	if err != nil {
+		l.Metrics.UnmarshalFailed()
 		l.connErr(conn, err)
 
 		return
 	}
 
-	if resp == nil {
-		l.connErr(conn, new(NilPageError))
+	ctx, cancel := l.requestContext(conn)
+	defer cancel()
+
+	ctx = trace.begin(withTraceparent(ctx, req.Args[types.ArgTraceparent]))
+
+	start := time.Now()
+
+	var (
+		resp     *types.Page
+		size     int
+		duration time.Duration
+	)
+
+	defer func() {
+		status := types.StatusError
+		if resp != nil {
+			status = resp.Status
+		}
+
+		l.Metrics.RequestServed(req.Verb, status, duration, size)
 
+		entry := accessLogEntry{
+			req:      req,
+			resp:     resp,
+			err:      err,
+			duration: time.Since(start),
+			size:     size,
+		}
+
+		if l.sampler.sample(l.AccessLogSampling, entry.isError()) {
+			logRequest(ctx, l.Logger, entry, l.AccessLogFields)
+		}
+	}()
+
+	handlerCtx, endHandler := trace.phase(ctx, SpanHandler)
+
+	resp, err = l.serve(handlerCtx, req)
+	duration = time.Since(start)
+
+	if err == nil && resp == nil {
+		err = new(NilPageError)
+	}
+
+	endHandler(err)
+
+	if err != nil {
+		resp = l.handlerErr(conn, req, err)
+	}
+
+	size = l.respond(ctx, trace, conn, req.ID, resp)
+}
+
+// handshake performs the server side of a DTLS handshake over conn, giving
+// up after handshakeTimeout, or when the Listener is closed
+func (l *Listener) handshake(conn net.Conn) (*dtls.Conn, error) {
+	ctx, cancel := context.WithTimeout(l.ctx, handshakeTimeout)
+	defer cancel()
+
+	return dtls.ServerWithContext(ctx, conn, l.listenerConfig)
+}
+
+// readRequest reads a whole request from conn, giving up after ReadTimeout
+func (l *Listener) readRequest(conn net.Conn) (data []byte, err error) {
+	if l.ReadTimeout > 0 {
+		err = conn.SetReadDeadline(time.Now().Add(l.ReadTimeout))
+		if err != nil {
+			return
+		}
+	}
+
+	data, err = frame.Read(conn, l.MaxRequestSize)
+	if err != nil {
 		return
 	}
 
-	buf = new(bytes.Buffer)
-	err = resp.Marshall(buf)
+	// Clear the deadline again, since requestContext relies on reads
+	// only ever returning once the connection is closed
+	err = conn.SetReadDeadline(time.Time{})
+
+	return
+}
+
+// respond marshalls resp and writes it to conn, tracing each as a child
+// of ctx, logging any errors, and returning the size of the marshalled
+// response.
+//
+// Where resp can't be marshalled, such as when a Handler returns an invalid
+// page, the client is sent a generic error page for the request id instead
+func (l *Listener) respond(ctx context.Context, trace *requestTrace, conn net.Conn, id uuid.UUID, resp *types.Page) (size int) {
+	buf := new(bytes.Buffer)
+
+	_, endMarshal := trace.phase(ctx, SpanMarshal)
+
+	err := resp.Marshall(buf)
+	endMarshal(err)
+
 	if err != nil {
 		l.connErr(conn, err)
 
-		return
+		buf.Reset()
+
+		err = errorPage(id, types.StatusError, "Internal Error").Marshall(buf)
+		if err != nil {
+			l.connErr(conn, err)
+
+			return
+		}
 	}
 
-	_, err = conn.Write(buf.Bytes())
+	_, endWrite := trace.phase(ctx, SpanWrite)
+
+	err = frame.Write(conn, buf.Bytes())
+	endWrite(err)
+
 	if err != nil {
 		l.connErr(conn, err)
+	}
+
+	return buf.Len()
+}
+
+// requestContext returns a context for a single request, which carries
+// the Peer on the other end of conn, and which is cancelled when the
+// Listener is closed, or when the client closes the connection
+func (l *Listener) requestContext(conn net.Conn) (ctx context.Context, cancel context.CancelFunc) {
+	ctx, cancel = context.WithCancel(withPeer(l.ctx, l.peer(conn)))
+
+	// Clients send nothing after their request, and so a read only
+	// ever returns when the connection is closed, either by the client
+	// or by us once the response is written
+	go func() {
+		//#nosec: G104
+		conn.Read(make([]byte, 1))
+		cancel()
+	}()
+
+	return
+}
+
+// peer describes the client on the other end of conn
+func (l *Listener) peer(conn net.Conn) (p Peer) {
+	p.RemoteAddr = conn.RemoteAddr()
 
+	dconn, ok := conn.(*dtls.Conn)
+	if !ok {
 		return
 	}
 
-	duration := time.Since(start)
-	l.logger.Info("Request",
-		zap.String("verb", verbToString(req.Verb)),
-		zap.String("document", req.ID.String()),
-		zap.String("remote_address", conn.RemoteAddr().String()),
-		zap.Duration("duration", duration),
-		zap.Bool("is_error", resp.Status == types.StatusError),
-		zap.Int("size", buf.Len()),
-	)
+	state := dconn.ConnectionState()
+
+	// Clients using pre-shared keys send the identity of the key they're
+	// using, and only complete the handshake where they know that key
+	if len(state.IdentityHint) > 0 {
+		p.PSKIdentity = string(state.IdentityHint)
+	}
+
+	certs := state.PeerCertificates
+	if len(certs) == 0 {
+		return
+	}
+
+	p.Fingerprint = fingerprint.Of(certs[0])
+
+	// pion/dtls has already parsed this certificate successfully
+	// during the handshake, and so this can't fail
+	//#nosec: G104
+	p.Certificate, _ = x509.ParseCertificate(certs[0])
+	if p.Certificate != nil {
+		p.Subject = p.Certificate.Subject.String()
+	}
+
+	// Where certificates are verified, handshakes with certificates
+	// that fail verification never get this far
+	p.Verified = l.listenerConfig.ClientAuth == dtls.VerifyClientCertIfGiven ||
+		l.listenerConfig.ClientAuth == dtls.RequireAndVerifyClientCert
+
+	return
+}
+
+// serve passes req to the Handler, wrapped in the Middleware every
+// Listener applies, turning panics into a PanicError
+func (l *Listener) serve(ctx context.Context, req *types.Request) (*types.Page, error) {
+	return Chain(l.handler,
+		ConditionalRead,
+		Timeout(l.RequestTimeout),
+		Recover,
+	).ServeContext(ctx, req)
+}
+
+// handlerErr returns the page to send to the client in place of the one
+// the Handler failed to return, logging err unless it's an ErrorPage, which
+// is an answer in its own right
+func (l Listener) handlerErr(conn net.Conn, req *types.Request, err error) *types.Page {
+	var ep ErrorPage
+	if errors.As(err, &ep) {
+		return ep.Page(req)
+	}
+
+	attrs := []any{
+		slog.Any("error", err),
+		slog.String("RemoteAddress", conn.RemoteAddr().String()),
+	}
+
+	var pe PanicError
+	if errors.As(err, &pe) {
+		attrs = append(attrs, slog.String("stack", string(pe.Stack)))
+	}
+
+	l.Logger.Error(err.Error(), attrs...)
+
+	return errorPage(req.ID, types.StatusError, "Internal Error")
 }
 
 func (l Listener) connErr(conn net.Conn, err error) {
-	l.logger.Error(err.Error(),
-		zap.Error(err),
-		zap.String("RemoteAddress", conn.RemoteAddr().String()),
+	l.Logger.Error(err.Error(),
+		slog.Any("error", err),
+		slog.String("RemoteAddress", conn.RemoteAddr().String()),
 	)
 }
 
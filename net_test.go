@@ -1,17 +1,25 @@
 package gordon
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"errors"
+	"log/slog"
 	"net"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/jspc/gordon/client"
+	"github.com/jspc/gordon/frame"
 	"github.com/jspc/gordon/types"
 	"github.com/pion/dtls/v2"
 	"github.com/pion/dtls/v2/pkg/crypto/selfsign"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 type dummyHandler struct {
@@ -44,12 +52,91 @@ func (nilNilHandler) Serve(*types.Request) (*types.Page, error) {
 	return nil, nil
 }
 
+type errorPageHandler struct {
+	err ErrorPage
+}
+
+func (h errorPageHandler) ServeContext(context.Context, *types.Request) (*types.Page, error) {
+	return nil, h.err
+}
+
 type emptyPageHandler struct{}
 
 func (emptyPageHandler) Serve(*types.Request) (*types.Page, error) {
 	return new(types.Page), nil
 }
 
+type slowHandler struct {
+	cancelled chan bool
+}
+
+func (h slowHandler) ServeContext(ctx context.Context, req *types.Request) (*types.Page, error) {
+	select {
+	case <-ctx.Done():
+		if h.cancelled != nil {
+			h.cancelled <- true
+		}
+
+		return nil, ctx.Err()
+
+	case <-time.After(time.Second):
+		return dummyHandler{}.Serve(req)
+	}
+}
+
+type drainHandler struct {
+	started chan bool
+	delay   time.Duration
+}
+
+func (h drainHandler) ServeContext(ctx context.Context, req *types.Request) (*types.Page, error) {
+	h.started <- true
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case <-time.After(h.delay):
+		return dummyHandler{}.Serve(req)
+	}
+}
+
+type stubbornHandler struct{}
+
+func (stubbornHandler) Serve(req *types.Request) (*types.Page, error) {
+	time.Sleep(time.Millisecond * 500)
+
+	return dummyHandler{}.Serve(req)
+}
+
+// startListener runs ListenAndServe in the background, returning once
+// the Listener is ready to accept connections. The error ListenAndServe
+// eventually returns is sent to the returned channel
+func startListener(t *testing.T, l *Listener, address string) <-chan error {
+	t.Helper()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- l.ListenAndServe(address)
+	}()
+
+	for i := 0; i < 100; i++ {
+		l.mu.Lock()
+		ready := l.listener != nil
+		l.mu.Unlock()
+
+		if ready {
+			return errs
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	t.Fatal("listener did not start")
+
+	return nil
+}
+
 func TestNewListener(t *testing.T) {
 	_, err := NewListener(new(dummyHandler), tls.Certificate{})
 	if err != nil {
@@ -60,49 +147,290 @@ func TestNewListener(t *testing.T) {
 func TestListener_ListenAndServe(t *testing.T) {
 	cert, _ := selfsign.GenerateSelfSigned()
 
-	l, _ := NewListener(new(dummyHandler), cert)
-	defer func() {
-		err := l.Close()
-		if err != nil {
-			t.Fatal(err)
-		}
-	}()
-
-	go l.ListenAndServe("localhost:4445")
-
-	addr, _ := client.ParseAddress("//localhost:4445/")
-
 	for _, test := range []struct {
-		name        string
-		handler     Handler
-		expectPage  *types.Page
-		expectError bool
+		name         string
+		address      string
+		handler      ContextHandler
+		expectPage   *types.Page
+		expectStatus types.Status
 	}{
-		{"Handler returns nothing on errors", dummyHandler{err: true}, nil, true},
-		{"Handler returns page when no error", dummyHandler{}, new(types.Page), false},
-		{"Nil pages from handler errors appropriately", nilNilHandler{}, nil, true},
-		{"Invalid pages from handler errors appropriately", emptyPageHandler{}, nil, true},
+		{"Handler errors are answered with an error page", "localhost:4445", AdaptHandler(dummyHandler{err: true}), &types.Page{Title: "Internal Error"}, types.StatusError},
+		{"Handler returns page when no error", "localhost:4455", AdaptHandler(dummyHandler{}), &types.Page{Title: "A Test Page"}, types.StatusOK},
+		{"Nil pages from handler errors appropriately", "localhost:4456", AdaptHandler(nilNilHandler{}), &types.Page{Title: "Internal Error"}, types.StatusError},
+		{"Invalid pages from handler errors appropriately", "localhost:4457", AdaptHandler(emptyPageHandler{}), &types.Page{Title: "Internal Error"}, types.StatusError},
+		{"Slow context handlers time out with an error page", "localhost:4458", slowHandler{}, &types.Page{Title: "Request Timed Out"}, types.StatusUnavailable},
+		{"Slow handlers which ignore context time out with an error page", "localhost:4459", AdaptHandler(stubbornHandler{}), &types.Page{Title: "Request Timed Out"}, types.StatusUnavailable},
+		{"Panicking handlers are answered with an error page", "localhost:4467", AdaptHandler(panickyHandler{}), &types.Page{Title: "Internal Error"}, types.StatusError},
+		{"ErrorPages are rendered for the client", "localhost:4468", errorPageHandler{ForbiddenError{Reason: "no"}}, &types.Page{Title: "Forbidden"}, types.StatusForbidden},
 	} {
 		t.Run(test.name, func(t *testing.T) {
-			l.handler = test.handler
+			l, _ := NewContextListener(test.handler, cert)
+			defer func() {
+				err := l.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}()
+
+			l.RequestTimeout = time.Millisecond * 100
+
+			startListener(t, &l, test.address)
+
+			addr, _ := client.ParseAddress("//" + test.address + "/")
 
 			rcvd, err := client.DoRequest(types.VerbRead, addr)
-			if err != nil && !test.expectError {
-				t.Errorf("unexpected error: %v", err)
-			} else if err == nil && test.expectError {
-				t.Error("expected error")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if rcvd.Title != test.expectPage.Title {
+				t.Errorf("expected title %q, received %q", test.expectPage.Title, rcvd.Title)
 			}
 
-			if (test.expectPage == nil) != (rcvd == nil) {
-				t.Errorf("expected\n\t%#v\nreceived\n\t%#v",
-					test.expectPage,
-					rcvd,
-				)
+			if rcvd.Status != test.expectStatus {
+				t.Errorf("expected status %v, received %v", test.expectStatus, rcvd.Status)
 			}
 		})
 	}
 }
 
+func TestListener_ListenAndServe_TimeoutsCancelContext(t *testing.T) {
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	h := slowHandler{cancelled: make(chan bool, 1)}
+
+	l, _ := NewContextListener(h, cert)
+	l.RequestTimeout = time.Millisecond * 100
+
+	defer l.Close()
+
+	startListener(t, &l, "localhost:4446")
+
+	addr, _ := client.ParseAddress("//localhost:4446/")
+
+	page, err := client.DoRequest(types.VerbRead, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if page.Status != types.StatusUnavailable {
+		t.Errorf("expected timeout page, received %#v", page)
+	}
+
+	select {
+	case <-h.cancelled:
+	case <-time.After(time.Second):
+		t.Error("handler context was never cancelled")
+	}
+}
+
+func TestListener_ListenAndServe_AccessLog(t *testing.T) {
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	l, _ := NewListener(new(dummyHandler), cert)
+	l.Logger = slog.New(NewZapHandler(zap.New(core)))
+
+	defer l.Close()
+
+	startListener(t, &l, "localhost:4465")
+
+	addr, _ := client.ParseAddress("//localhost:4465/")
+
+	_, err := client.DoRequest(types.VerbRead, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The access log is written once the response has been sent, and
+	// so may not have been written by the time we've received it
+	for i := 0; i < 100 && logs.FilterMessage("Request").Len() == 0; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	entries := logs.FilterMessage("Request").All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 log line, received %d", len(entries))
+	}
+
+	fields := entries[0].ContextMap()
+	if size, _ := fields["size"].(int64); size == 0 {
+		t.Errorf("expected response size to be logged, received %#v", fields["size"])
+	}
+
+	if fields["verb"] != "read" {
+		t.Errorf("expected verb %q, received %#v", "read", fields["verb"])
+	}
+}
+
+func TestListener_Close_BeforeListenAndServe(t *testing.T) {
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	l, _ := NewListener(new(dummyHandler), cert)
+
+	err := l.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = l.ListenAndServe("localhost:4447")
+	if !errors.Is(err, ErrListenerClosed) {
+		t.Errorf("expected ErrListenerClosed, received %v", err)
+	}
+}
+
+func TestListener_Shutdown(t *testing.T) {
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	h := drainHandler{started: make(chan bool, 1), delay: time.Millisecond * 200}
+
+	l, _ := NewContextListener(h, cert)
+	errs := startListener(t, &l, "localhost:4450")
+
+	addr, _ := client.ParseAddress("//localhost:4450/")
+
+	type result struct {
+		page *types.Page
+		err  error
+	}
+
+	c := make(chan result, 1)
+	go func() {
+		page, err := client.DoRequest(types.VerbRead, addr)
+		c <- result{page, err}
+	}()
+
+	<-h.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := l.Shutdown(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := <-c
+	if r.err != nil {
+		t.Errorf("in-flight request failed: %v", r.err)
+	}
+
+	if r.page == nil || r.page.Status != types.StatusOK {
+		t.Errorf("expected page, received %#v", r.page)
+	}
+
+	err = <-errs
+	if !errors.Is(err, ErrListenerClosed) {
+		t.Errorf("expected ErrListenerClosed, received %v", err)
+	}
+}
+
+func TestListener_Shutdown_ContextExpires(t *testing.T) {
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	h := drainHandler{started: make(chan bool, 1), delay: time.Second * 5}
+
+	l, _ := NewContextListener(h, cert)
+	errs := startListener(t, &l, "localhost:4451")
+
+	addr, _ := client.ParseAddress("//localhost:4451/")
+
+	//#nosec: G104
+	go client.DoRequest(types.VerbRead, addr)
+
+	<-h.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	err := l.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, received %v", err)
+	}
+
+	err = <-errs
+	if !errors.Is(err, ErrListenerClosed) {
+		t.Errorf("expected ErrListenerClosed, received %v", err)
+	}
+}
+
+func TestListener_Shutdown_ContextExpiresClosesConnections(t *testing.T) {
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	l, _ := NewListener(new(dummyHandler), cert)
+	startListener(t, &l, "localhost:4464")
+
+	// Connect, but never send a request, leaving the Listener waiting
+	// on a read which won't return until the connection is closed
+	dial(t, "localhost:4464")
+
+	for i := 0; i < 100 && len(openConns(&l)) == 0; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	err := l.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, received %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		if l.requestPool.TryAcquire(l.poolSize) {
+			return
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	t.Error("stalled connection was never released")
+}
+
+func TestListener_Shutdown_BeforeListenAndServe(t *testing.T) {
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	l, _ := NewListener(new(dummyHandler), cert)
+
+	err := l.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = l.ListenAndServe("localhost:4452")
+	if !errors.Is(err, ErrListenerClosed) {
+		t.Errorf("expected ErrListenerClosed, received %v", err)
+	}
+}
+
+func TestAdaptHandler(t *testing.T) {
+	t.Run("Handlers are wrapped", func(t *testing.T) {
+		h := AdaptHandler(dummyHandler{})
+
+		p, err := h.ServeContext(context.Background(), new(types.Request))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if p.Title != "A Test Page" {
+			t.Errorf("unexpected page %#v", p)
+		}
+	})
+
+	t.Run("ContextHandlers are returned as-is", func(t *testing.T) {
+		h := struct {
+			Handler
+			ContextHandler
+		}{dummyHandler{}, slowHandler{}}
+
+		rcvd := AdaptHandler(h)
+		if _, ok := rcvd.(handlerAdapter); ok {
+			t.Errorf("expected ContextHandler to be returned, received adapter")
+		}
+	})
+}
+
 func TestListener_ListenAndServe_DodgyListenAddress(t *testing.T) {
 	cert, _ := selfsign.GenerateSelfSigned()
 
@@ -125,9 +453,9 @@ func TestListener_ListenAndServe_NonDTLSAreSilentlyDiscarded(t *testing.T) {
 		}
 	}()
 
-	go l.ListenAndServe("localhost:4445")
+	startListener(t, &l, "localhost:4448")
 
-	addr, _ := net.ResolveUDPAddr("udp", "localhost:4445")
+	addr, _ := net.ResolveUDPAddr("udp", "localhost:4448")
 
 	c, err := net.DialUDP("udp", nil, addr)
 	if err != nil {
@@ -158,31 +486,204 @@ func TestListener_ListenAndServe_NonMintEncodedDocumentFails(t *testing.T) {
 		l.Close()
 	}()
 
-	go l.ListenAndServe("localhost:4445")
+	startListener(t, &l, "localhost:4449")
+
+	conn := dial(t, "localhost:4449")
+
+	err := frame.Write(conn, []byte("hello, world!"))
+	if err != nil {
+		t.Error(err)
+	}
+
+	buf := make([]byte, 1024)
+	_, err = conn.Read(buf)
+	if err == nil {
+		t.Errorf("expected error, received none with buffer %q", string(buf))
+	}
+}
+
+func TestListener_ListenAndServe_LargeRequests(t *testing.T) {
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	l, _ := NewContextListener(HandlerFunc(func(_ context.Context, req *types.Request) (*types.Page, error) {
+		return &types.Page{
+			Title:  strconv.Itoa(len(req.Args["Body"])),
+			Status: types.StatusOK,
+		}, nil
+	}), cert)
+	l.MaxRequestSize = 100 * 1024
+
+	defer l.Close()
+
+	startListener(t, &l, "localhost:4453")
+
+	for _, test := range []struct {
+		name        string
+		size        int
+		expectTitle string
+	}{
+		{"Requests spanning many records are reassembled", 50 * 1024, "51200"},
+		{"Requests larger than MaxRequestSize are rejected", 200 * 1024, "Request Too Large"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			page, err := doRequest(t, "localhost:4453", &types.Request{
+				Verb: types.VerbCreate,
+				Args: map[string]string{
+					"Body": strings.Repeat("a", test.size),
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if page.Title != test.expectTitle {
+				t.Errorf("expected %q, received %q", test.expectTitle, page.Title)
+			}
+		})
+	}
+}
+
+func TestListener_ListenAndServe_TruncatedRequests(t *testing.T) {
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	l, _ := NewListener(new(dummyHandler), cert)
+	l.MaxConnections = 1
+	l.ReadTimeout = time.Millisecond * 100
+
+	defer l.Close()
+
+	startListener(t, &l, "localhost:4466")
+
+	// Claim a 10KiB request, and then send only the first 10 bytes of it
+	conn := dial(t, "localhost:4466")
+
+	_, err := conn.Write([]byte{0, 0, 40, 0, 0, 0, 0, 0, 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j'})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// With only one connection allowed at a time, this request can only
+	// be served once the truncated request above has been given up on
+	addr, _ := client.ParseAddress("//localhost:4466/")
+
+	c, _ := client.NewClient()
+	c.ReadTimeout = time.Second
+
+	_, err = c.Get(context.Background(), addr)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestListener_ListenAndServe_LargeResponses(t *testing.T) {
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	body := strings.Repeat("some text nobody will read\n", 10000)
+
+	l, _ := NewContextListener(HandlerFunc(func(context.Context, *types.Request) (*types.Page, error) {
+		return &types.Page{
+			Title: "A Large Page",
+			Sections: []types.Section{
+				{Title: "Chapter 1", Body: body},
+			},
+			Status: types.StatusOK,
+		}, nil
+	}), cert)
+
+	defer l.Close()
+
+	startListener(t, &l, "localhost:4454")
+
+	addr, _ := client.ParseAddress("//localhost:4454/")
+
+	t.Run("Responses spanning many records are reassembled", func(t *testing.T) {
+		page, err := client.DoRequest(types.VerbRead, addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if page.Sections[0].Body != body {
+			t.Errorf("response body was corrupted")
+		}
+	})
+
+	t.Run("Responses larger than MaxResponseSize are rejected", func(t *testing.T) {
+		c, _ := client.NewClient()
+		c.MaxResponseSize = 1024
+
+		_, err := c.Get(context.Background(), addr)
+		if !errors.As(err, new(frame.TooLargeError)) {
+			t.Errorf("expected frame.TooLargeError, received %v", err)
+		}
+	})
+}
+
+// openConns returns the connections l is still tracking
+func openConns(l *Listener) (conns []net.Conn) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for conn := range l.conns {
+		conns = append(conns, conn)
+	}
+
+	return
+}
+
+// dial opens a DTLS connection to address, closing it once the test is done
+func dial(t *testing.T, address string) *dtls.Conn {
+	t.Helper()
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
-	addr, _ := net.ResolveUDPAddr("udp", "localhost:4445")
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	addr, _ := net.ResolveUDPAddr("udp", address)
 	conn, err := dtls.DialWithContext(ctx, "udp", addr, &dtls.Config{
 		Certificates:         []tls.Certificate{cert},
 		InsecureSkipVerify:   true,
 		ExtendedMasterSecret: dtls.RequireExtendedMasterSecret,
 	})
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 
-	_, err = conn.Write([]byte("hello, world!"))
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return conn
+}
+
+// doRequest sends req, which may carry Args, to address and returns the
+// Page sent back
+func doRequest(t *testing.T, address string, req *types.Request) (page *types.Page, err error) {
+	t.Helper()
+
+	conn := dial(t, address)
+
+	buf := new(bytes.Buffer)
+
+	err = req.Marshall(buf)
 	if err != nil {
-		t.Error(err)
+		return
 	}
 
-	buf := make([]byte, 1024)
-	_, err = conn.Read(buf)
-	if err == nil {
-		t.Errorf("expected error, received none with buffer %q", string(buf))
+	// Servers may answer, and hang up, before the whole request has been
+	// sent, such as when rejecting requests which are too large, and so
+	// a failed write may still have a response waiting to be read
+	werr := frame.Write(conn, buf.Bytes())
+
+	data, err := frame.Read(conn, 0)
+	if err != nil {
+		return nil, errors.Join(werr, err)
 	}
+
+	page = new(types.Page)
+	err = page.Unmarshall(bytes.NewBuffer(data))
+
+	return
 }
 
 func TestVerbToString(t *testing.T) {
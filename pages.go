@@ -0,0 +1,69 @@
+package gordon
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jspc/gordon/types"
+)
+
+// errorPage returns a minimal, valid, error page for the document id,
+// with the given status, which is sent to clients when something has gone
+// wrong on our side rather than in a Handler
+func errorPage(id uuid.UUID, status types.Status, title string) *types.Page {
+	return &types.Page{
+		Title:  title,
+		Status: status,
+		Meta: types.Metadata{
+			ID:        id,
+			Author:    "Gordon",
+			Published: time.Now(),
+		},
+	}
+}
+
+// notModifiedPage returns the page sent in place of the page described by
+// meta, where the client already has an up to date copy of it
+func notModifiedPage(meta types.Metadata) *types.Page {
+	return &types.Page{
+		Title:  "Not Modified",
+		Status: types.StatusNotModified,
+		Meta:   meta,
+	}
+}
+
+// Redirect returns a page telling the client which made req that the
+// document it asked for lives at to instead, which may be on another
+// server entirely, where to.Server is set.
+//
+//	func (s server) ServeContext(ctx context.Context, req *types.Request) (*types.Page, error) {
+//		if to, ok := s.moved[req.ID]; ok {
+//			return gordon.Redirect(req, to), nil
+//		}
+//
+//		// ...
+//	}
+//
+// Clients with MaxRedirects set follow redirects themselves
+func Redirect(req *types.Request, to types.PageRef) *types.Page {
+	return &types.Page{
+		Title:    "Redirect",
+		Status:   types.StatusRedirect,
+		Location: to,
+		Meta: types.Metadata{
+			ID:        req.ID,
+			Author:    "Gordon",
+			Published: time.Now(),
+		},
+	}
+}
+
+// RedirectHandler returns a ContextHandler which redirects every request
+// it serves to to, such as for use with ServeMux.HandlePage where a
+// document has moved
+func RedirectHandler(to types.PageRef) ContextHandler {
+	return HandlerFunc(func(_ context.Context, req *types.Request) (*types.Page, error) {
+		return Redirect(req, to), nil
+	})
+}
@@ -0,0 +1,39 @@
+package gordon
+
+import (
+	"github.com/jspc/gordon/internal/psk"
+	"github.com/pion/dtls/v2"
+)
+
+// A PSK configures a Listener to authenticate clients with pre-shared keys,
+// rather than certificates, for networks where running a PKI isn't worth the
+// bother.
+//
+// Each client identifies itself with an identity, which Key turns into the
+// key shared with that client. Once a client has successfully completed a
+// handshake, its identity is available to Handlers via PeerFromContext
+type PSK struct {
+	// IdentityHint is sent to clients during the handshake to help them
+	// choose which key to use, and may be left empty
+	IdentityHint []byte
+
+	// Key returns the key shared with the client identifying itself as
+	// identity. Returning an error, such as for unknown identities, fails
+	// the handshake
+	Key func(identity []byte) ([]byte, error)
+}
+
+// NewPSKListener accepts a ContextHandler and a PSK and configures a
+// Listener in the same way as NewContextListener, except that clients are
+// authenticated with pre-shared keys rather than certificates.
+//
+// Neither side presents a certificate, and so ClientAuth and ClientCAs are
+// ignored
+func NewPSKListener(h ContextHandler, p PSK) (l Listener, err error) {
+	return newListener(h, &dtls.Config{
+		PSK:                  p.Key,
+		PSKIdentityHint:      p.IdentityHint,
+		CipherSuites:         psk.CipherSuites,
+		ExtendedMasterSecret: dtls.RequireExtendedMasterSecret,
+	})
+}
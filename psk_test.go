@@ -0,0 +1,81 @@
+package gordon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jspc/gordon/client"
+)
+
+func TestNewPSKListener(t *testing.T) {
+	keys := map[string][]byte{
+		"machine-a": []byte("some secret key"),
+	}
+
+	l, err := NewPSKListener(HandlerFunc(peerHandler), PSK{
+		IdentityHint: []byte("gordon"),
+		Key: func(identity []byte) ([]byte, error) {
+			key, ok := keys[string(identity)]
+			if !ok {
+				return nil, errors.New("unknown identity")
+			}
+
+			return key, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer l.Close()
+
+	startListener(t, &l, "localhost:4475")
+
+	addr, _ := client.ParseAddress("//localhost:4475/")
+
+	for _, test := range []struct {
+		name        string
+		identity    string
+		key         []byte
+		expectError bool
+	}{
+		{"Unknown identities are refused", "machine-b", []byte("some secret key"), true},
+		{"Incorrect keys are refused", "machine-a", []byte("some other key"), true},
+		{"Known identities with the right key are served", "machine-a", []byte("some secret key"), false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := client.NewClient()
+			c.DialTimeout = time.Millisecond * 500
+			c.PSKIdentity = []byte(test.identity)
+			c.PSK = func(hint []byte) ([]byte, error) {
+				if !bytes.Equal(hint, []byte("gordon")) {
+					t.Errorf("unexpected identity hint %q", hint)
+				}
+
+				return test.key, nil
+			}
+
+			page, err := c.Get(context.Background(), addr)
+			if err != nil && !test.expectError {
+				t.Fatalf("unexpected error: %v", err)
+			} else if err == nil && test.expectError {
+				t.Fatal("expected error")
+			}
+
+			if test.expectError {
+				return
+			}
+
+			if page.Labels["psk"] != test.identity {
+				t.Errorf("expected identity %q, received %q", test.identity, page.Labels["psk"])
+			}
+
+			if page.Labels["subject"] != "" {
+				t.Errorf("expected no certificate, received %q", page.Labels["subject"])
+			}
+		})
+	}
+}
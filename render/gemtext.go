@@ -0,0 +1,106 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jspc/gordon/markup"
+)
+
+// gemtext renders pages as gemtext which, having no inline markup of its
+// own, renders text as FormatText does. Index links are references, such
+// as [0], to link lines at the end of the page
+func gemtext(w *writer, d *document) {
+	t := textRenderer{writer: w}
+
+	w.print("# ", clean(d.Title), "\n\n")
+
+	if d.Preamble != "" {
+		w.print(clean(d.Preamble), "\n\n")
+	}
+
+	if b := d.byline(); b != "" {
+		w.print(clean(b), "\n\n")
+	}
+
+	if s := d.status(); s != "" {
+		w.print("Status: ", s, "\n\n")
+	}
+
+	if d.location != "" {
+		location := clean(d.location)
+
+		w.print("=> ", location, " Redirects to ", location, "\n\n")
+	}
+
+	for _, s := range d.sections {
+		w.print("## ", clean(s.title), "\n\n")
+
+		for _, b := range s.blocks {
+			gemtextBlock(t, b)
+		}
+	}
+
+	if len(d.Tags) > 0 {
+		w.print("Tags: ", clean(strings.Join(d.Tags, ", ")), "\n\n")
+	}
+
+	if len(d.Labels) > 0 {
+		w.print("### Labels\n\n")
+
+		for _, k := range d.labels() {
+			w.print("* ", clean(k), ": ", clean(d.Labels[k]), "\n")
+		}
+
+		w.print("\n")
+	}
+
+	if len(d.links) > 0 {
+		w.print("### Links\n\n")
+
+		for i, l := range d.links {
+			w.print("=> ", clean(l), " [", strconv.Itoa(i), "]\n")
+		}
+
+		w.print("\n")
+	}
+
+	if len(d.relationships) > 0 {
+		w.print("### Relationships\n\n")
+
+		for _, r := range d.relationships {
+			w.print("* ", clean(r), "\n")
+		}
+
+		w.print("\n")
+	}
+}
+
+func gemtextBlock(t textRenderer, b markup.Block) {
+	switch b := b.(type) {
+	case *markup.Heading:
+		// Gemtext only has three levels of heading, the first two of
+		// which are taken by the page and section titles
+		t.print("### ", t.inline(b.Content), "\n")
+
+	case *markup.Paragraph:
+		t.lines("", b.Lines)
+
+	case *markup.List:
+		t.lines("* ", b.Items)
+
+	case *markup.Quote:
+		t.lines("> ", b.Lines)
+
+	case *markup.CodeBlock:
+		t.print("```", clean(b.Lang), "\n")
+
+		if b.Code != "" {
+			t.print(clean(b.Code), "\n")
+		}
+
+		t.print("```\n")
+	}
+
+	t.print("\n")
+}
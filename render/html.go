@@ -0,0 +1,192 @@
+package render
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jspc/gordon/markup"
+)
+
+func htmlDocument(w *writer, d *document) {
+	w.print(`<!DOCTYPE html>
+<html lang="`, html.EscapeString(d.lang), `">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>`, html.EscapeString(d.Title), `</title>
+</head>
+<body>
+<main>
+<article>
+<header>
+<h1>`, html.EscapeString(d.Title), "</h1>\n")
+
+	if d.Preamble != "" {
+		w.print("<p>", html.EscapeString(d.Preamble), "</p>\n")
+	}
+
+	htmlByline(w, d)
+
+	if s := d.status(); s != "" {
+		w.print("<p><strong>Status:</strong> ", s, "</p>\n")
+	}
+
+	if d.location != "" {
+		w.print("<p>Redirects to ", htmlLink(d.location), "</p>\n")
+	}
+
+	w.print("</header>\n")
+
+	for i, s := range d.sections {
+		id := "section-" + strconv.Itoa(i)
+
+		w.print(`<section aria-labelledby="`, id, `">`, "\n")
+		w.print(`<h2 id="`, id, `">`, html.EscapeString(s.title), "</h2>\n")
+
+		for _, b := range s.blocks {
+			htmlBlock(w, d, b)
+		}
+
+		w.print("</section>\n")
+	}
+
+	htmlFooter(w, d)
+
+	w.print("</article>\n</main>\n</body>\n</html>\n")
+}
+
+func htmlByline(w *writer, d *document) {
+	var parts []string
+
+	if d.Meta.Author != "" {
+		parts = append(parts, "By "+html.EscapeString(d.Meta.Author))
+	}
+
+	if !d.Meta.Published.IsZero() {
+		parts = append(parts, `<time datetime="`+d.Meta.Published.UTC().Format(time.RFC3339)+`">`+published(d.Meta.Published)+"</time>")
+	}
+
+	if len(parts) == 0 {
+		return
+	}
+
+	w.print("<p>", strings.Join(parts, ", "))
+
+	if d.Meta.Revision != "" {
+		w.print(" (revision ", html.EscapeString(d.Meta.Revision), ")")
+	}
+
+	w.print("</p>\n")
+}
+
+func htmlFooter(w *writer, d *document) {
+	if len(d.Tags) == 0 && len(d.Labels) == 0 && len(d.relationships) == 0 {
+		return
+	}
+
+	w.print("<footer>\n<dl>\n")
+
+	if len(d.Tags) > 0 {
+		w.print("<dt>Tags</dt>\n")
+
+		for _, t := range d.Tags {
+			w.print("<dd>", html.EscapeString(t), "</dd>\n")
+		}
+	}
+
+	if len(d.Labels) > 0 {
+		w.print("<dt>Labels</dt>\n")
+
+		for _, k := range d.labels() {
+			w.print("<dd>", html.EscapeString(k), ": ", html.EscapeString(d.Labels[k]), "</dd>\n")
+		}
+	}
+
+	if len(d.relationships) > 0 {
+		w.print("<dt>Relationships</dt>\n")
+
+		for _, r := range d.relationships {
+			w.print("<dd>", html.EscapeString(r), "</dd>\n")
+		}
+	}
+
+	w.print("</dl>\n</footer>\n")
+}
+
+func htmlBlock(w *writer, d *document, b markup.Block) {
+	switch b := b.(type) {
+	case *markup.Heading:
+		// Headings within sections sit below the h2 of the section
+		// itself
+		tag := "h" + strconv.Itoa(b.Level+2)
+
+		w.print("<", tag, ">", htmlInline(d, b.Content), "</", tag, ">\n")
+
+	case *markup.Paragraph:
+		w.print("<p>", htmlLines(d, b.Lines), "</p>\n")
+
+	case *markup.List:
+		w.print("<ul>\n")
+
+		for _, item := range b.Items {
+			w.print("<li>", htmlInline(d, item), "</li>\n")
+		}
+
+		w.print("</ul>\n")
+
+	case *markup.Quote:
+		w.print("<blockquote>\n<p>", htmlLines(d, b.Lines), "</p>\n</blockquote>\n")
+
+	case *markup.CodeBlock:
+		w.print("<pre><code")
+
+		if b.Lang != "" {
+			w.print(` class="language-`, html.EscapeString(b.Lang), `"`)
+		}
+
+		w.print(">", html.EscapeString(b.Code), "</code></pre>\n")
+	}
+}
+
+func htmlLines(d *document, lines [][]markup.Node) string {
+	s := make([]string, len(lines))
+	for i, l := range lines {
+		s[i] = htmlInline(d, l)
+	}
+
+	return strings.Join(s, "<br>\n")
+}
+
+func htmlInline(d *document, nodes []markup.Node) string {
+	sb := new(strings.Builder)
+
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *markup.Text:
+			sb.WriteString(html.EscapeString(n.Value))
+
+		case *markup.Emphasis:
+			sb.WriteString("<em>" + htmlInline(d, n.Children) + "</em>")
+
+		case *markup.Code:
+			sb.WriteString("<code>" + html.EscapeString(n.Value) + "</code>")
+
+		case *markup.Link:
+			if addr, ok := d.link(n); ok {
+				sb.WriteString(htmlLink(addr))
+			} else {
+				sb.WriteString(html.EscapeString(markup.String([]markup.Node{n})))
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+func htmlLink(addr string) string {
+	addr = html.EscapeString(addr)
+
+	return `<a href="` + addr + `">` + addr + "</a>"
+}
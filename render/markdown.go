@@ -0,0 +1,175 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/jspc/gordon/markup"
+)
+
+// markdownEscaper escapes characters which would otherwise be read as
+// Markdown
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	`*`, `\*`,
+	`_`, `\_`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`<`, `\<`,
+	`>`, `\>`,
+)
+
+func markdown(w *writer, d *document) {
+	w.print("# ", markdownEscape(d.Title), "\n\n")
+
+	if d.Preamble != "" {
+		w.print(markdownLine(markdownEscape(d.Preamble)), "\n\n")
+	}
+
+	if b := d.byline(); b != "" {
+		w.print("*", markdownEscape(b), "*\n\n")
+	}
+
+	if s := d.status(); s != "" {
+		w.print("**Status:** ", s, "\n\n")
+	}
+
+	if d.location != "" {
+		w.print("Redirects to ", markdownLink(d.location), "\n\n")
+	}
+
+	for _, s := range d.sections {
+		w.print("## ", markdownEscape(s.title), "\n\n")
+
+		for _, b := range s.blocks {
+			markdownBlock(w, d, b)
+		}
+	}
+
+	if len(d.Tags) == 0 && len(d.Labels) == 0 && len(d.relationships) == 0 {
+		return
+	}
+
+	w.print("---\n\n")
+
+	if len(d.Tags) > 0 {
+		tags := make([]string, len(d.Tags))
+		for i, t := range d.Tags {
+			tags[i] = markdownEscape(t)
+		}
+
+		w.print("**Tags:** ", strings.Join(tags, ", "), "\n\n")
+	}
+
+	if len(d.Labels) > 0 {
+		w.print("**Labels:**\n\n")
+
+		for _, k := range d.labels() {
+			w.print("- ", markdownEscape(k), ": ", markdownEscape(d.Labels[k]), "\n")
+		}
+
+		w.print("\n")
+	}
+
+	if len(d.relationships) > 0 {
+		w.print("**Relationships:**\n\n")
+
+		for _, r := range d.relationships {
+			w.print("- ", markdownEscape(r), "\n")
+		}
+
+		w.print("\n")
+	}
+}
+
+func markdownBlock(w *writer, d *document, b markup.Block) {
+	switch b := b.(type) {
+	case *markup.Heading:
+		// Headings within sections sit below the heading of the
+		// section itself
+		w.print(strings.Repeat("#", b.Level+2), " ", markdownInline(d, b.Content), "\n")
+
+	case *markup.Paragraph:
+		for i, l := range b.Lines {
+			// Hard line breaks, so that lines stay lines
+			if i > 0 {
+				w.print("\\\n")
+			}
+
+			w.print(markdownLine(markdownInline(d, l)))
+		}
+
+		w.print("\n")
+
+	case *markup.List:
+		for _, item := range b.Items {
+			w.print("- ", markdownInline(d, item), "\n")
+		}
+
+	case *markup.Quote:
+		for _, l := range b.Lines {
+			w.print("> ", markdownInline(d, l), "\n")
+		}
+
+	case *markup.CodeBlock:
+		// Fences must be longer than any run of backticks in the code
+		fence := "```"
+		for strings.Contains(b.Code, fence) {
+			fence += "`"
+		}
+
+		w.print(fence, b.Lang, "\n")
+
+		if b.Code != "" {
+			w.print(b.Code, "\n")
+		}
+
+		w.print(fence, "\n")
+	}
+
+	w.print("\n")
+}
+
+func markdownInline(d *document, nodes []markup.Node) string {
+	sb := new(strings.Builder)
+
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *markup.Text:
+			sb.WriteString(markdownEscape(n.Value))
+
+		case *markup.Emphasis:
+			sb.WriteString("*" + markdownInline(d, n.Children) + "*")
+
+		case *markup.Code:
+			sb.WriteString("`" + n.Value + "`")
+
+		case *markup.Link:
+			if addr, ok := d.link(n); ok {
+				sb.WriteString(markdownLink(addr))
+			} else {
+				sb.WriteString(markdownEscape(markup.String([]markup.Node{n})))
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// markdownLine escapes characters at the start of an already escaped line
+// which would otherwise make it a heading, list, or thematic break
+func markdownLine(s string) string {
+	if strings.HasPrefix(s, "#") || strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") || strings.HasPrefix(s, "=") {
+		return `\` + s
+	}
+
+	return s
+}
+
+func markdownLink(addr string) string {
+	return "[" + markdownEscape(addr) + "](<" + addr + ">)"
+}
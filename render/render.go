@@ -0,0 +1,308 @@
+// Package render turns gordon pages into documents for people to read: text
+// for terminals, Markdown, standalone HTML, and gemtext.
+//
+// Section bodies are parsed with the markup package, and index links are
+// resolved against each page's Links. Bodies which aren't well formed are
+// rendered as best they can be, rather than failing; index links which
+// don't resolve are rendered as written
+package render
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jspc/gordon/markup"
+	"github.com/jspc/gordon/types"
+)
+
+// Format is a format pages may be rendered in
+type Format uint8
+
+const (
+	// FormatText is plain text
+	FormatText Format = iota
+
+	// FormatANSI is text for terminals, styled with ANSI escape codes
+	FormatANSI
+
+	// FormatMarkdown is CommonMark
+	FormatMarkdown
+
+	// FormatHTML is a standalone HTML document
+	FormatHTML
+
+	// FormatGemtext is gemtext, as served by gemini capsules
+	FormatGemtext
+)
+
+var formatNames = []string{"text", "ansi", "markdown", "html", "gemtext"}
+
+// ErrUnknownFormat is returned when rendering, or parsing, a Format which
+// doesn't exist
+var ErrUnknownFormat = errors.New("unknown format")
+
+// ParseFormat returns the Format named s, case insensitively, such as
+// "markdown" or "html"
+func ParseFormat(s string) (Format, error) {
+	idx := slices.Index(formatNames, strings.ToLower(s))
+	if idx < 0 {
+		return 0, fmt.Errorf("%w %q; expected one of %s", ErrUnknownFormat, s, strings.Join(formatNames, ", "))
+	}
+
+	return Format(idx), nil
+}
+
+// String returns the name of f, as accepted by ParseFormat
+func (f Format) String() string {
+	if int(f) >= len(formatNames) {
+		return "unknown"
+	}
+
+	return formatNames[f]
+}
+
+// Render writes p to w in Format f, with the defaults of a Renderer
+func Render(w io.Writer, p *types.Page, f Format) error {
+	return Renderer{Format: f}.Render(w, p)
+}
+
+// A Renderer renders pages in a specific Format. The zero value renders
+// pages as plain text
+type Renderer struct {
+	// Format is the Format to render pages in
+	Format Format
+
+	// Server is the server pages were read from, such as
+	// example.com:4444, which links without a server of their own
+	// point to
+	Server string
+
+	// Link, where set, returns the address rendered for ref in place of
+	// the default, such as where pages are rendered to HTML served over
+	// HTTP. The default is of the form //server/page-id#section
+	Link func(ref types.PageRef) string
+
+	// Lang is the language pages are written in, as a BCP 47 tag, which
+	// FormatHTML declares so that screen readers pronounce pages
+	// properly. Where unset, pages are declared to be in English
+	Lang string
+}
+
+// Render writes p to w
+func (r Renderer) Render(w io.Writer, p *types.Page) error {
+	var fn func(*writer, *document)
+
+	switch r.Format {
+	case FormatText:
+		fn = text(false)
+
+	case FormatANSI:
+		fn = text(true)
+
+	case FormatMarkdown:
+		fn = markdown
+
+	case FormatHTML:
+		fn = htmlDocument
+
+	case FormatGemtext:
+		fn = gemtext
+
+	default:
+		return fmt.Errorf("%w %d", ErrUnknownFormat, r.Format)
+	}
+
+	ew := &writer{w: w}
+	fn(ew, r.document(p))
+
+	return ew.err
+}
+
+// address returns the address rendered for ref
+func (r Renderer) address(ref types.PageRef) string {
+	if r.Link != nil {
+		return r.Link(ref)
+	}
+
+	server := ref.Server
+	if server == "" {
+		server = r.Server
+	}
+
+	addr := "//" + server + "/" + ref.Page.String()
+	if server == "" {
+		addr = "/" + ref.Page.String()
+	}
+
+	if ref.Section != "" {
+		addr += "#" + ref.Section
+	}
+
+	return addr
+}
+
+// document is a page, readied for rendering
+type document struct {
+	*types.Page
+
+	sections      []section
+	links         []string
+	relationships []string
+
+	// location is the address a redirect points to
+	location string
+
+	lang string
+}
+
+type section struct {
+	title  string
+	blocks []markup.Block
+}
+
+func (r Renderer) document(p *types.Page) *document {
+	d := &document{
+		Page:     p,
+		sections: make([]section, len(p.Sections)),
+		links:    make([]string, len(p.Links)),
+		lang:     r.Lang,
+	}
+
+	if d.lang == "" {
+		d.lang = "en"
+	}
+
+	for i, ref := range p.Links {
+		d.links[i] = r.address(ref)
+	}
+
+	// Relationships are described as sentences, such as
+	// "//example.com/a supplements //example.com/b"
+	for _, rel := range p.Relationships {
+		d.relationships = append(d.relationships, r.address(rel.Subject)+" "+predicate(rel.Predicate)+" "+r.address(rel.Object))
+	}
+
+	if p.Status == types.StatusRedirect {
+		d.location = r.address(p.Location)
+	}
+
+	for i, s := range p.Sections {
+		// Bodies which aren't well formed, or which have links
+		// which don't resolve, are rendered anyway; see package docs
+		blocks, _ := markup.Parse(s.Body)
+
+		//#nosec: G104
+		markup.ResolveBlocks(blocks, p.Links)
+
+		d.sections[i] = section{title: s.Title, blocks: blocks}
+	}
+
+	return d
+}
+
+// link returns the address of l, and whether it resolved
+func (d *document) link(l *markup.Link) (string, bool) {
+	if l.Ref == nil {
+		return "", false
+	}
+
+	return d.links[l.Index], true
+}
+
+// labels returns the keys of Labels in order
+func (d *document) labels() []string {
+	keys := make([]string, 0, len(d.Labels))
+	for k := range d.Labels {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+// status returns a description of the page's status, or an empty string
+// where the page is a plain old page
+func (d *document) status() string {
+	if d.Status == types.StatusOK {
+		return ""
+	}
+
+	return statusNames[d.Status]
+}
+
+var statusNames = map[types.Status]string{
+	types.StatusError:       "Error",
+	types.StatusNotFound:    "Not Found",
+	types.StatusForbidden:   "Forbidden",
+	types.StatusBadRequest:  "Bad Request",
+	types.StatusRedirect:    "Redirect",
+	types.StatusNotModified: "Not Modified",
+	types.StatusTooLarge:    "Too Large",
+	types.StatusUnavailable: "Unavailable",
+}
+
+func predicate(p types.Predicate) string {
+	switch p {
+	case types.PredicateHasChild:
+		return "has child"
+
+	case types.PredicateExtends:
+		return "extends"
+
+	case types.PredicateSupercedes:
+		return "supercedes"
+
+	case types.PredicateSupplements:
+		return "supplements"
+	}
+
+	return "relates to"
+}
+
+// published returns the time a page was published, for people to read
+func published(t time.Time) string {
+	return t.UTC().Format("2 January 2006, 15:04 MST")
+}
+
+// writer writes strings to w until an error occurs, after which it does
+// nothing, so that renderers needn't check every write
+type writer struct {
+	w   io.Writer
+	err error
+}
+
+func (w *writer) print(s ...string) {
+	for _, str := range s {
+		if w.err != nil {
+			return
+		}
+
+		_, w.err = io.WriteString(w.w, str)
+	}
+}
+
+// byline describes who published the page, and when, such as "By jspc,
+// 1 May 2024, 12:00 UTC", or returns an empty string where neither is known
+func (d *document) byline() string {
+	var parts []string
+
+	if d.Meta.Author != "" {
+		parts = append(parts, "By "+d.Meta.Author)
+	}
+
+	if !d.Meta.Published.IsZero() {
+		parts = append(parts, published(d.Meta.Published))
+	}
+
+	s := strings.Join(parts, ", ")
+	if s != "" && d.Meta.Revision != "" {
+		s += " (revision " + d.Meta.Revision + ")"
+	}
+
+	return s
+}
@@ -0,0 +1,210 @@
+package render
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jspc/gordon/types"
+)
+
+var (
+	linkedID  = uuid.Must(uuid.FromString("996b046f-11d2-41c9-8b45-9294c7215e38"))
+	renderID  = uuid.Must(uuid.FromString("208b43d9-a95d-476d-ba3b-3b64fda2507b"))
+	otherHost = "other.example.com:4444"
+)
+
+func renderPage() *types.Page {
+	return &types.Page{
+		Title:    "Fish & <Chips>",
+		Preamble: "All about chips",
+		Meta: types.Metadata{
+			ID:        renderID,
+			Author:    "jspc",
+			Published: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+			Revision:  "r1",
+		},
+		Sections: []types.Section{
+			{Title: "Introduction", Body: "# Frying\n\nChips are *very [l:0]* good\nsee [l:1] and [l:5]\n\n* salt\n* vinegar\n\n> quoth\n\n```go\nfry(chips) // <3\n```\n"},
+		},
+		Tags:   []string{"food"},
+		Labels: map[string]string{"colour": "golden"},
+		Links: []types.PageRef{
+			{Page: linkedID},
+			{Page: linkedID, Server: otherHost, Section: "Batter"},
+		},
+		Relationships: []types.Relationship{
+			{Subject: types.PageRef{Page: renderID}, Predicate: types.PredicateSupplements, Object: types.PageRef{Page: linkedID}},
+		},
+		Status: types.StatusOK,
+	}
+}
+
+func TestRender(t *testing.T) {
+	local := "//example.com:4444/" + linkedID.String()
+	remote := "//" + otherHost + "/" + linkedID.String() + "#Batter"
+
+	for _, test := range []struct {
+		format       Format
+		expect       []string
+		expectAbsent []string
+	}{
+		{FormatText, []string{
+			"Fish & <Chips>\n==============\n",
+			"By jspc, 1 May 2024, 12:00 UTC (revision r1)",
+			"Introduction\n------------\n",
+			"Chips are *very [0]* good\nsee [1] and [l:5]\n",
+			"  • salt\n  • vinegar\n",
+			"  │ quoth\n",
+			"    fry(chips) // <3\n",
+			"Tags: food",
+			"  colour: golden\n",
+			"  [0] " + local + "\n  [1] " + remote + "\n",
+			"//example.com:4444/" + renderID.String() + " supplements " + local,
+		}, []string{"\x1b["}},
+		{FormatANSI, []string{
+			"\x1b[1m\x1b[4mFish & <Chips>\x1b[0m",
+			"\x1b[3mvery \x1b[4m[0]\x1b[0m\x1b[0m",
+		}, nil},
+		{FormatMarkdown, []string{
+			"# Fish & \\<Chips\\>\n",
+			"## Introduction\n\n### Frying\n",
+			"Chips are *very [" + local + "](<" + local + ">)* good\\\nsee [" + remote + "](<" + remote + ">) and \\[l:5\\]\n",
+			"- salt\n- vinegar\n",
+			"> quoth\n",
+			"```go\nfry(chips) // <3\n```\n",
+			"**Tags:** food",
+		}, nil},
+		{FormatHTML, []string{
+			"<!DOCTYPE html>\n<html lang=\"en\">",
+			"<title>Fish &amp; &lt;Chips&gt;</title>",
+			`<time datetime="2024-05-01T12:00:00Z">`,
+			`<section aria-labelledby="section-0">` + "\n" + `<h2 id="section-0">Introduction</h2>` + "\n<h3>Frying</h3>",
+			`<p>Chips are <em>very <a href="` + local + `">` + local + `</a></em> good<br>` + "\n",
+			"<ul>\n<li>salt</li>\n<li>vinegar</li>\n</ul>",
+			"<blockquote>\n<p>quoth</p>\n</blockquote>",
+			`<pre><code class="language-go">fry(chips) // &lt;3</code></pre>`,
+			"<dt>Labels</dt>\n<dd>colour: golden</dd>",
+		}, []string{"<Chips>"}},
+		{FormatGemtext, []string{
+			"# Fish & <Chips>\n",
+			"## Introduction\n\n### Frying\n",
+			"Chips are *very [0]* good\nsee [1] and [l:5]\n",
+			"* salt\n* vinegar\n",
+			"```go\nfry(chips) // <3\n```\n",
+			"=> " + local + " [0]\n=> " + remote + " [1]\n",
+		}, nil},
+	} {
+		t.Run(test.format.String(), func(t *testing.T) {
+			sb := new(strings.Builder)
+
+			err := Renderer{Format: test.format, Server: "example.com:4444"}.Render(sb, renderPage())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out := sb.String()
+
+			for _, e := range test.expect {
+				if !strings.Contains(out, e) {
+					t.Errorf("expected output to contain %q\n%s", e, out)
+				}
+			}
+
+			for _, e := range test.expectAbsent {
+				if strings.Contains(out, e) {
+					t.Errorf("expected output not to contain %q\n%s", e, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRender_ControlCharacters(t *testing.T) {
+	p := &types.Page{
+		Title:    "evil\x1b]0;pwned\x07\x1b[2J",
+		Preamble: "\u009b31m",
+		Meta:     types.Metadata{Author: "\x1b[8mjspc"},
+		Sections: []types.Section{
+			{Title: "\rOne", Body: "some\x1b[2J *text\x1b[5m* `co\x1bde`\n\n```\n\tfunc\x1b[0m\n```\n"},
+		},
+		Tags:   []string{"\x1b[1mtag"},
+		Labels: map[string]string{"k\x1b": "v\x1b"},
+		Status: types.StatusOK,
+	}
+
+	for _, f := range []Format{FormatText, FormatANSI, FormatGemtext} {
+		t.Run(f.String(), func(t *testing.T) {
+			sb := new(strings.Builder)
+
+			err := Render(sb, p, f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out := sb.String()
+
+			for _, e := range []string{"evil]0;pwned[2J", "some[2J", "code", "\tfunc[0m", "tag"} {
+				if !strings.Contains(out, e) {
+					t.Errorf("expected output to contain %q\n%q", e, out)
+				}
+			}
+
+			// Renderers' own styling is all that's left
+			out = strings.NewReplacer(ansiReset, "", ansiBold, "", ansiDim, "", ansiItalic, "", ansiUnderline, "", ansiCyan, "").Replace(out)
+
+			for _, r := range out {
+				if unicode.IsControl(r) && r != '\n' && r != '\t' {
+					t.Errorf("unexpected control character %q in\n%q", r, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRender_Redirect(t *testing.T) {
+	p := &types.Page{
+		Title:    "Redirect",
+		Status:   types.StatusRedirect,
+		Location: types.PageRef{Page: linkedID},
+	}
+
+	sb := new(strings.Builder)
+
+	err := Render(sb, p, FormatGemtext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range []string{"Status: Redirect", "=> /" + linkedID.String() + " Redirects to"} {
+		if !strings.Contains(sb.String(), e) {
+			t.Errorf("expected output to contain %q\n%s", e, sb.String())
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range []Format{FormatText, FormatANSI, FormatMarkdown, FormatHTML, FormatGemtext} {
+		received, err := ParseFormat(strings.ToUpper(f.String()))
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", f, err)
+		}
+
+		if received != f {
+			t.Errorf("expected %s, received %s", f, received)
+		}
+	}
+
+	_, err := ParseFormat("pdf")
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, received %v", err)
+	}
+
+	err = Render(new(strings.Builder), renderPage(), Format(99))
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, received %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jspc/gordon/markup"
+)
+
+// ANSI escape codes used by FormatANSI
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiDim       = "\x1b[2m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+	ansiCyan      = "\x1b[36m"
+)
+
+// textRenderer renders pages as text, styled with ANSI escape codes where
+// ansi is set. Index links are rendered as references, such as [0], to a
+// list of Links at the end of the page
+type textRenderer struct {
+	*writer
+
+	ansi bool
+}
+
+func text(ansi bool) func(*writer, *document) {
+	return func(w *writer, d *document) {
+		textRenderer{writer: w, ansi: ansi}.page(d)
+	}
+}
+
+// clean returns s without any control characters other than newlines and
+// tabs, so that pages can't send escape codes of their own to the terminal.
+// Everything a page sets is cleaned before it's styled and written
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+
+		return r
+	}, s)
+}
+
+// style returns s styled with code, where ansi is set
+func (t textRenderer) style(code, s string) string {
+	if !t.ansi {
+		return s
+	}
+
+	return code + s + ansiReset
+}
+
+// heading writes a heading, which is styled where ansi is set, and
+// otherwise underlined with rule
+func (t textRenderer) heading(s string, rule string, code string) {
+	s = clean(s)
+
+	if t.ansi {
+		t.print(t.style(code, s), "\n\n")
+
+		return
+	}
+
+	t.print(s, "\n", strings.Repeat(rule, utf8.RuneCountInString(s)), "\n\n")
+}
+
+func (t textRenderer) page(d *document) {
+	t.heading(d.Title, "=", ansiBold+ansiUnderline)
+
+	if d.Preamble != "" {
+		t.print(clean(d.Preamble), "\n\n")
+	}
+
+	if b := d.byline(); b != "" {
+		t.print(t.style(ansiDim, clean(b)), "\n\n")
+	}
+
+	if s := d.status(); s != "" {
+		t.print("Status: ", t.style(ansiBold, s), "\n\n")
+	}
+
+	if d.location != "" {
+		t.print("Redirects to ", t.style(ansiUnderline, clean(d.location)), "\n\n")
+	}
+
+	for _, s := range d.sections {
+		t.heading(s.title, "-", ansiBold)
+
+		for _, b := range s.blocks {
+			t.block(b)
+		}
+	}
+
+	if len(d.Tags) > 0 {
+		t.print(t.style(ansiBold, "Tags:"), " ", clean(strings.Join(d.Tags, ", ")), "\n\n")
+	}
+
+	labels := d.labels()
+	for i, k := range labels {
+		labels[i] = k + ": " + d.Labels[k]
+	}
+
+	links := make([]string, len(d.links))
+	for i, l := range d.links {
+		links[i] = "[" + strconv.Itoa(i) + "] " + l
+	}
+
+	t.list("Labels", labels)
+	t.list("Links", links)
+	t.list("Relationships", d.relationships)
+}
+
+func (t textRenderer) list(title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+
+	t.print(t.style(ansiBold, title+":"), "\n")
+
+	for _, item := range items {
+		t.print("  ", clean(item), "\n")
+	}
+
+	t.print("\n")
+}
+
+func (t textRenderer) block(b markup.Block) {
+	switch b := b.(type) {
+	case *markup.Heading:
+		t.print(t.style(ansiBold, t.inline(b.Content)), "\n")
+
+	case *markup.Paragraph:
+		t.lines("", b.Lines)
+
+	case *markup.List:
+		t.lines("  • ", b.Items)
+
+	case *markup.Quote:
+		t.lines("  │ ", b.Lines)
+
+	case *markup.CodeBlock:
+		for _, l := range strings.Split(b.Code, "\n") {
+			t.print("    ", t.style(ansiCyan, clean(l)), "\n")
+		}
+	}
+
+	t.print("\n")
+}
+
+func (t textRenderer) lines(prefix string, lines [][]markup.Node) {
+	for _, l := range lines {
+		t.print(prefix, t.inline(l), "\n")
+	}
+}
+
+func (t textRenderer) inline(nodes []markup.Node) string {
+	sb := new(strings.Builder)
+
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *markup.Text:
+			sb.WriteString(clean(n.Value))
+
+		case *markup.Emphasis:
+			if t.ansi {
+				sb.WriteString(t.style(ansiItalic, t.inline(n.Children)))
+			} else {
+				sb.WriteString("*" + t.inline(n.Children) + "*")
+			}
+
+		case *markup.Code:
+			if t.ansi {
+				sb.WriteString(t.style(ansiCyan, clean(n.Value)))
+			} else {
+				sb.WriteString("`" + clean(n.Value) + "`")
+			}
+
+		case *markup.Link:
+			if n.Ref == nil {
+				sb.WriteString(markup.String([]markup.Node{n}))
+			} else {
+				sb.WriteString(t.style(ansiUnderline, "["+strconv.Itoa(n.Index)+"]"))
+			}
+		}
+	}
+
+	return sb.String()
+}
@@ -77,7 +77,7 @@ var mintDoc = &types.Page{
 	Sections: []types.Section{
 		{
 			Title: "page.mint",
-			Body: `type Page {
+			Body: "```mint\n" + `type Page {
      +mint:doc:"Metadata contains metadata about this page, including"
      +mint:doc:"useful stuff like author details, and revisions, and dates"
      +mint:doc:"and all that stuff"
@@ -145,11 +145,11 @@ type Link {
 enum Status {
      OK
      Error
-}`,
+}` + "\n```\n",
 		},
 		{
 			Title: "request.mint",
-			Body: `enum Verb {
+			Body: "```mint\n" + `enum Verb {
      Create
      Read
      Update
@@ -172,7 +172,7 @@ type Request {
      +mint:doc:"such as Section for an Update, or Body for both Create and Update"
      map<string,string> Args = 2;
 }
-`,
+` + "```\n",
 		},
 	},
 	Tags: []string{"gordon", "protocol", "mint"},
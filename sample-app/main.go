@@ -1,7 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gofrs/uuid/v5"
 	"github.com/jspc/gordon"
@@ -9,7 +16,14 @@ import (
 	"github.com/pion/dtls/v2/pkg/crypto/selfsign"
 )
 
+var (
+	certs   = flag.String("certs", "", "Directory containing tls.crt and tls.key, which are checked for changes every 30 seconds. Where unset a self-signed certificate is generated at start-up")
+	metrics = flag.String("metrics", "", "Address to serve Prometheus metrics on, such as 127.0.0.1:9100. Where unset metrics are not served")
+)
+
 func main() {
+	flag.Parse()
+
 	fmt.Println("gordon")
 
 	s := Server{
@@ -19,15 +33,60 @@ func main() {
 		},
 	}
 
-	certificate, err := selfsign.GenerateSelfSigned()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	src, err := certificateSource(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	l, err := gordon.NewListenerWithCertificateSource(s.Mux(), src)
 	if err != nil {
 		panic(err)
 	}
 
-	l, err := gordon.NewListener(s, certificate)
+	if *metrics != "" {
+		l.Metrics = gordon.NewPrometheusMetrics()
+		l.MetricsAddress = *metrics
+	}
+
+	shutdown := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+
+		shutdown <- l.Shutdown(ctx)
+	}()
+
+	err = l.ListenAndServe("0.0.0.0:4444")
+	if !errors.Is(err, gordon.ErrListenerClosed) {
+		panic(err)
+	}
+
+	err = <-shutdown
 	if err != nil {
 		panic(err)
 	}
+}
+
+// certificateSource returns the CertificateSource set by the -certs flag,
+// which is watched for changes until ctx is cancelled
+func certificateSource(ctx context.Context) (gordon.CertificateSource, error) {
+	if *certs != "" {
+		src, err := gordon.NewDirectoryCertificateSource(*certs)
+		if err != nil {
+			return nil, err
+		}
+
+		go src.Watch(ctx, time.Second*30)
+
+		return src, nil
+	}
+
+	certificate, err := selfsign.GenerateSelfSigned()
 
-	panic(l.ListenAndServe("0.0.0.0:4444"))
+	return gordon.StaticCertificate(certificate), err
 }
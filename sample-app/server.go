@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/gofrs/uuid/v5"
+	"github.com/jspc/gordon"
 	"github.com/jspc/gordon/types"
 )
 
@@ -12,21 +14,21 @@ type Server struct {
 	pages map[uuid.UUID]*types.Page
 }
 
-func (s Server) Serve(req *types.Request) (resp *types.Page, err error) {
-	switch req.Verb {
-	case types.VerbRead:
-		return s.serveRead(req)
+// Mux returns a gordon.ServeMux which routes requests to s; anything
+// other than a Read is answered by the mux as not being supported
+func (s Server) Mux() *gordon.ServeMux {
+	mux := gordon.NewServeMux()
+	mux.HandlePage(types.VerbRead, uuid.Nil, gordon.HandlerFunc(s.serveIndex))
+	mux.Handle(types.VerbRead, gordon.HandlerFunc(s.serveRead))
 
-	default:
-		return s.verbNotSupported(req.ID), nil
-	}
+	return mux
 }
 
-func (s Server) serveRead(req *types.Request) (resp *types.Page, err error) {
-	if req.ID.IsNil() {
-		return s.indexPage(), nil
-	}
+func (s Server) serveIndex(context.Context, *types.Request) (resp *types.Page, err error) {
+	return s.indexPage(), nil
+}
 
+func (s Server) serveRead(_ context.Context, req *types.Request) (resp *types.Page, err error) {
 	resp, ok := s.pages[req.ID]
 	if ok {
 		return
@@ -63,17 +65,13 @@ func (s Server) indexPage() (p *types.Page) {
 }
 
 func (s Server) pageNotFound(id uuid.UUID) *types.Page {
-	return s.error(id, "Page Not Found")
-}
-
-func (s Server) verbNotSupported(id uuid.UUID) *types.Page {
-	return s.error(id, "Verb Not Supported")
+	return s.error(id, types.StatusNotFound, "Page Not Found")
 }
 
-func (s Server) error(id uuid.UUID, msg string) *types.Page {
+func (s Server) error(id uuid.UUID, status types.Status, msg string) *types.Page {
 	return &types.Page{
 		Title:  msg,
-		Status: types.StatusError,
+		Status: status,
 		Meta: types.Metadata{
 			ID:        id,
 			Author:    "Gordon",
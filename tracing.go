@@ -0,0 +1,155 @@
+package gordon
+
+import (
+	"context"
+	"time"
+)
+
+// The names of the spans a Listener traces for each request. SpanRequest
+// covers the whole request, and is the parent of the others
+const (
+	SpanRequest   = "gordon.request"
+	SpanHandshake = "gordon.handshake"
+	SpanUnmarshal = "gordon.unmarshal"
+	SpanHandler   = "gordon.handler"
+	SpanMarshal   = "gordon.marshal"
+	SpanWrite     = "gordon.write"
+)
+
+// A Tracer starts spans for each phase of every request a Listener serves,
+// so that requests can be traced through whichever tracing system is in
+// use, such as OpenTelemetry.
+//
+// The handshake, and the reading and unmarshalling of the request, happen
+// before the client's traceparent is known. These phases are traced once
+// they have finished, with the times they started and ended at, so that
+// they still belong to the client's trace.
+//
+// Methods are called from many goroutines at once, and so implementations
+// must be safe for concurrent use
+type Tracer interface {
+	// StartSpan starts the span name at start, as a child of the span ctx
+	// carries, returning a context carrying the new span.
+	//
+	// Where ctx carries no span, the new span continues the trace
+	// identified by TraceparentFromContext instead, if there is one
+	StartSpan(ctx context.Context, name string, start time.Time) (context.Context, Span)
+}
+
+// A Span is a single phase of a request, started by a Tracer
+type Span interface {
+	// End ends the span at end, with the error the phase failed with, if
+	// it failed
+	End(end time.Time, err error)
+}
+
+// nopTracer is the Tracer every Listener starts with, and traces nothing
+type nopTracer struct{}
+
+func (nopTracer) StartSpan(ctx context.Context, _ string, _ time.Time) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) End(time.Time, error) {}
+
+type traceparentKey struct{}
+
+// TraceparentFromContext returns the W3C traceparent the client sent
+// with the request ctx belongs to, in the types.ArgTraceparent Arg
+func TraceparentFromContext(ctx context.Context) (traceparent string, ok bool) {
+	traceparent, ok = ctx.Value(traceparentKey{}).(string)
+
+	return
+}
+
+func withTraceparent(ctx context.Context, traceparent string) context.Context {
+	if traceparent == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, traceparentKey{}, traceparent)
+}
+
+// requestTrace traces a single request. Phases which finish before the
+// request span can be started are kept until it is
+type requestTrace struct {
+	tracer Tracer
+	start  time.Time
+
+	ctx     context.Context
+	span    Span
+	pending []finishedPhase
+}
+
+type finishedPhase struct {
+	name       string
+	start, end time.Time
+	err        error
+}
+
+func newRequestTrace(tracer Tracer) *requestTrace {
+	return &requestTrace{
+		tracer: tracer,
+		start:  time.Now(),
+	}
+}
+
+// finished traces the phase name, which ran from start until now
+func (t *requestTrace) finished(name string, start time.Time, err error) {
+	p := finishedPhase{
+		name:  name,
+		start: start,
+		end:   time.Now(),
+		err:   err,
+	}
+
+	if t.span == nil {
+		t.pending = append(t.pending, p)
+
+		return
+	}
+
+	t.trace(p)
+}
+
+// begin starts the request span as a child of ctx, tracing any phases
+// which have already finished, and returns the context to run the rest of
+// the request with
+func (t *requestTrace) begin(ctx context.Context) context.Context {
+	t.ctx, t.span = t.tracer.StartSpan(ctx, SpanRequest, t.start)
+
+	for _, p := range t.pending {
+		t.trace(p)
+	}
+
+	t.pending = nil
+
+	return t.ctx
+}
+
+// phase starts the span name now, as a child of ctx, returning the context
+// to run the phase with, and a function to end it with
+func (t *requestTrace) phase(ctx context.Context, name string) (context.Context, func(err error)) {
+	ctx, span := t.tracer.StartSpan(ctx, name, time.Now())
+
+	return ctx, func(err error) {
+		span.End(time.Now(), err)
+	}
+}
+
+// end ends the request span, starting it first where the request failed
+// before begin was called
+func (t *requestTrace) end(parent context.Context, err error) {
+	if t.span == nil {
+		t.begin(parent)
+	}
+
+	t.span.End(time.Now(), err)
+}
+
+func (t *requestTrace) trace(p finishedPhase) {
+	_, span := t.tracer.StartSpan(t.ctx, p.name, p.start)
+	span.End(p.end, p.err)
+}
@@ -0,0 +1,179 @@
+package gordon
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jspc/gordon/client"
+	"github.com/jspc/gordon/types"
+	"github.com/pion/dtls/v2/pkg/crypto/selfsign"
+)
+
+// recordingTracer records each span it starts, along with the name of its
+// parent, or the traceparent it continues where it has no parent
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+	ended chan *recordedSpan
+}
+
+type recordedSpan struct {
+	name   string
+	parent string
+	start  time.Time
+	end    time.Time
+	err    error
+
+	tracer *recordingTracer
+}
+
+type spanKey struct{}
+
+func newRecordingTracer() *recordingTracer {
+	return &recordingTracer{
+		ended: make(chan *recordedSpan, 16),
+	}
+}
+
+func (r *recordingTracer) StartSpan(ctx context.Context, name string, start time.Time) (context.Context, Span) {
+	s := &recordedSpan{
+		name:   name,
+		start:  start,
+		tracer: r,
+	}
+
+	if parent, ok := ctx.Value(spanKey{}).(*recordedSpan); ok {
+		s.parent = parent.name
+	} else if traceparent, ok := TraceparentFromContext(ctx); ok {
+		s.parent = traceparent
+	}
+
+	r.mu.Lock()
+	r.spans = append(r.spans, s)
+	r.mu.Unlock()
+
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func (s *recordedSpan) End(end time.Time, err error) {
+	s.tracer.mu.Lock()
+	s.end = end
+	s.err = err
+	s.tracer.mu.Unlock()
+
+	s.tracer.ended <- s
+}
+
+type traceparentHandler struct {
+	traceparent chan string
+}
+
+func (h traceparentHandler) ServeContext(ctx context.Context, _ *types.Request) (*types.Page, error) {
+	tp, _ := TraceparentFromContext(ctx)
+	h.traceparent <- tp
+
+	return dummyHandler{}.Serve(nil)
+}
+
+func TestListener_ListenAndServe_Tracing(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	cert, _ := selfsign.GenerateSelfSigned()
+
+	tracer := newRecordingTracer()
+	h := traceparentHandler{traceparent: make(chan string, 1)}
+
+	l, _ := NewContextListener(h, cert)
+	l.Tracer = tracer
+
+	defer l.Close()
+
+	startListener(t, &l, "localhost:4479")
+
+	c, _ := client.NewClient()
+	c.Traceparent = func(context.Context) string {
+		return traceparent
+	}
+
+	addr, _ := client.ParseAddress("//localhost:4479/")
+
+	_, err := c.Get(context.Background(), addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if received := <-h.traceparent; received != traceparent {
+		t.Errorf("expected traceparent %q, received %q", traceparent, received)
+	}
+
+	// The request span is the last to end, once the response has been
+	// written
+	for {
+		select {
+		case s := <-tracer.ended:
+			if s.name != SpanRequest {
+				continue
+			}
+
+		case <-time.After(time.Second):
+			t.Fatal("request span never ended")
+		}
+
+		break
+	}
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+
+	names := make([]string, len(tracer.spans))
+	for i, s := range tracer.spans {
+		names[i] = s.name
+
+		if s.err != nil {
+			t.Errorf("%s: unexpected error %#v", s.name, s.err)
+		}
+
+		if s.end.Before(s.start) {
+			t.Errorf("%s: ended before it started", s.name)
+		}
+
+		expectParent := SpanRequest
+		if s.name == SpanRequest {
+			expectParent = traceparent
+		}
+
+		if s.parent != expectParent {
+			t.Errorf("%s: expected parent %q, received %q", s.name, expectParent, s.parent)
+		}
+	}
+
+	expect := []string{SpanRequest, SpanHandshake, SpanUnmarshal, SpanHandler, SpanMarshal, SpanWrite}
+	if !slices.Equal(names, expect) {
+		t.Errorf("expected spans %v, received %v", expect, names)
+	}
+}
+
+func TestRequestTrace_HandshakeFailed(t *testing.T) {
+	tracer := newRecordingTracer()
+
+	trace := newRequestTrace(tracer)
+	trace.finished(SpanHandshake, trace.start, ErrListenerClosed)
+	trace.end(context.Background(), ErrListenerClosed)
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("expected 2 spans, received %d", len(tracer.spans))
+	}
+
+	for _, s := range tracer.spans {
+		if s.err != ErrListenerClosed {
+			t.Errorf("%s: expected error %v, received %v", s.name, ErrListenerClosed, s.err)
+		}
+	}
+
+	if tracer.spans[1].parent != SpanRequest {
+		t.Errorf("expected handshake span to belong to the request span, received %q", tracer.spans[1].parent)
+	}
+}
@@ -14,6 +14,8 @@ type Metadata struct {
 	Author string
 	// Published date of the resource
 	Published time.Time
+	// Revision identifies this version of the resource, and should change whenever the resource does, so that clients can tell whether copies they already have are up to date
+	Revision string
 }
 
 func (sf Metadata) Validate() error {
@@ -23,7 +25,7 @@ func (sf Metadata) Validate() error {
 			errors = append(errors, err)
 		}
 	}
-	return mint.ValidationErrors("Metadata", errors)
+	return wrapValidationErrors(mint.ValidationErrors("Metadata", errors), errors)
 }
 func (sf *Metadata) Transform() (err error) {
 	sf.Published, err = mint.DateInUtc(sf.Published)
@@ -62,6 +64,15 @@ func (sf *Metadata) unmarshallPublished(r io.Reader) (err error) {
 	sf.Published = f.Value().(time.Time)
 	return
 }
+func (sf *Metadata) unmarshallRevision(r io.Reader) (err error) {
+	f := mint.NewStringScalar("")
+	err = f.Unmarshall(r)
+	if err != nil {
+		return
+	}
+	sf.Revision = f.Value().(string)
+	return
+}
 func (sf *Metadata) Unmarshall(r io.Reader) (err error) {
 	if err = sf.unmarshallID(r); err != nil {
 		return
@@ -72,6 +83,9 @@ func (sf *Metadata) Unmarshall(r io.Reader) (err error) {
 	if err = sf.unmarshallPublished(r); err != nil {
 		return
 	}
+	if err = sf.unmarshallRevision(r); err != nil {
+		return
+	}
 	if err = sf.Transform(); err != nil {
 		return
 	}
@@ -96,5 +110,8 @@ func (sf Metadata) Marshall(w io.Writer) (err error) {
 	if err = mint.NewDatetimeScalar(sf.Published).Marshall(w); err != nil {
 		return
 	}
+	if err = mint.NewStringScalar(sf.Revision).Marshall(w); err != nil {
+		return
+	}
 	return
 }
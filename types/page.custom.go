@@ -1,5 +1,156 @@
 package types
 
+import (
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/jspc/gordon/internal/linktoken"
+)
+
+// FieldErrors returns every problem with sf, by field, in a single list. It
+// returns nil where sf is valid.
+//
+// The error returned by Validate unwraps to the same problems, and so may
+// also be inspected with errors.As
+func (sf Page) FieldErrors() (errs FieldErrors) {
+	if sf.Title == "" {
+		errs.add("Title", "must not be empty")
+	}
+
+	for _, check := range []func() FieldErrors{
+		sf.titleErrors,
+		sf.sectionErrors,
+		sf.tagErrors,
+		sf.labelErrors,
+		sf.relationshipErrors,
+	} {
+		errs = append(errs, check()...)
+	}
+
+	return
+}
+
+// TitleNotTooLong ensures titles are no longer than MaxTitleLength
+// characters
 func (sf Page) TitleNotTooLong(string, any) error {
-	return nil
+	return sf.titleErrors().err()
+}
+
+// SectionsValid ensures section titles are unique, and that index links in
+// section bodies point to elements of Links
+func (sf Page) SectionsValid(string, any) error {
+	return sf.sectionErrors().err()
+}
+
+// TagsValid ensures there are no more than MaxTags tags, and that each is
+// between 1 and MaxTagLength characters
+func (sf Page) TagsValid(string, any) error {
+	return sf.tagErrors().err()
+}
+
+// LabelsValid ensures there are no more than MaxLabels labels, with keys of
+// between 1 and MaxLabelKeyLength characters, and values of no more than
+// MaxLabelValueLength characters
+func (sf Page) LabelsValid(string, any) error {
+	return sf.labelErrors().err()
+}
+
+// RelationshipsValid ensures each relationship has a Predicate, Subject,
+// and Object
+func (sf Page) RelationshipsValid(string, any) error {
+	return sf.relationshipErrors().err()
+}
+
+func (sf Page) titleErrors() (errs FieldErrors) {
+	if n := utf8.RuneCountInString(sf.Title); n > MaxTitleLength {
+		errs.add("Title", "is %d characters, which is longer than the maximum of %d", n, MaxTitleLength)
+	}
+
+	return
+}
+
+func (sf Page) sectionErrors() (errs FieldErrors) {
+	titles := make(map[string]int, len(sf.Sections))
+
+	for i, s := range sf.Sections {
+		if first, ok := titles[s.Title]; ok {
+			errs.add(sectionField(i, "Title"), "%q is also the title of Sections[%d]", s.Title, first)
+		} else {
+			titles[s.Title] = i
+		}
+
+		for _, tok := range linktoken.Find(s.Body) {
+			if tok.Index >= len(sf.Links) {
+				errs.add(sectionField(i, "Body"), "link %s points past the end of Links, which has %d elements", s.Body[tok.Start:tok.End], len(sf.Links))
+			}
+		}
+	}
+
+	return
+}
+
+func (sf Page) tagErrors() (errs FieldErrors) {
+	if len(sf.Tags) > MaxTags {
+		errs.add("Tags", "has %d tags, which is more than the maximum of %d", len(sf.Tags), MaxTags)
+	}
+
+	for i, tag := range sf.Tags {
+		field := "Tags[" + strconv.Itoa(i) + "]"
+
+		switch n := utf8.RuneCountInString(tag); {
+		case n == 0:
+			errs.add(field, "must not be empty")
+		case n > MaxTagLength:
+			errs.add(field, "is %d characters, which is longer than the maximum of %d", n, MaxTagLength)
+		}
+	}
+
+	return
+}
+
+func (sf Page) labelErrors() (errs FieldErrors) {
+	if len(sf.Labels) > MaxLabels {
+		errs.add("Labels", "has %d labels, which is more than the maximum of %d", len(sf.Labels), MaxLabels)
+	}
+
+	for _, k := range sortedKeys(sf.Labels) {
+		field := "Labels[" + k + "]"
+
+		switch n := utf8.RuneCountInString(k); {
+		case n == 0:
+			errs.add(field, "key must not be empty")
+		case n > MaxLabelKeyLength:
+			errs.add(field, "key is %d characters, which is longer than the maximum of %d", n, MaxLabelKeyLength)
+		}
+
+		if n := utf8.RuneCountInString(sf.Labels[k]); n > MaxLabelValueLength {
+			errs.add(field, "value is %d characters, which is longer than the maximum of %d", n, MaxLabelValueLength)
+		}
+	}
+
+	return
+}
+
+func (sf Page) relationshipErrors() (errs FieldErrors) {
+	for i, r := range sf.Relationships {
+		field := "Relationships[" + strconv.Itoa(i) + "]"
+
+		if r.Predicate == PredicateUnknown {
+			errs.add(field+".Predicate", "must be set")
+		}
+
+		if r.Subject.Page.IsNil() {
+			errs.add(field+".Subject", "must point to a page")
+		}
+
+		if r.Object.Page.IsNil() {
+			errs.add(field+".Object", "must point to a page")
+		}
+	}
+
+	return
+}
+
+func sectionField(i int, field string) string {
+	return "Sections[" + strconv.Itoa(i) + "]." + field
 }
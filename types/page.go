@@ -14,28 +14,30 @@ type Page struct {
 	Title string
 	// Preamble is used on Page Indexes and other list operations
 	Preamble string
-	// Sections contains a list of sections in a document. A section is how we divvy up data
+	// Sections contains a list of sections in a document. A section is how we divvy up data. Section titles must be unique, and index links within section bodies must point to elements of Links
 	Sections []Section
-	// Tags are an arbitrary list of strings to attach to a page for searching and organising
+	// Tags are an arbitrary list of strings to attach to a page for searching and organising. There may be up to 64 tags, of between 1 and 128 characters each
 	Tags []string
-	// Labels are Much like tags; handy for searching and organising data
+	// Labels are Much like tags; handy for searching and organising data. There may be up to 64 labels, with keys of between 1 and 128 characters, and values of up to 1024 characters
 	Labels map[string]string
 	// Links are references to other pages; within the body of a section, they are referenced by their index
 	Links []PageRef
-	// Relationships are used to link pages
+	// Relationships are used to link pages, and must each have a Predicate, Subject, and Object
 	Relationships []Relationship
-	// Status reflects whether this page is to be treated as an error page or not
+	// Status reflects whether this page is to be treated as an error page or not, and if so what sort of error
 	Status Status
+	// Location is the page a Redirect points to, which may be on another server entirely
+	Location PageRef
 }
 
 func (sf Page) Validate() error {
 	errors := make([]error, 0)
-	for _, err := range []error{mint.StringNotEmpty("Title", sf.Title), sf.TitleNotTooLong("Title", sf.Title)} {
+	for _, err := range []error{mint.StringNotEmpty("Title", sf.Title), sf.TitleNotTooLong("Title", sf.Title), sf.SectionsValid("Sections", sf.Sections), sf.TagsValid("Tags", sf.Tags), sf.LabelsValid("Labels", sf.Labels), sf.RelationshipsValid("Relationships", sf.Relationships)} {
 		if err != nil {
 			errors = append(errors, err)
 		}
 	}
-	return mint.ValidationErrors("Page", errors)
+	return wrapValidationErrors(mint.ValidationErrors("Page", errors), errors)
 }
 func (sf *Page) Transform() (err error) {
 	return
@@ -222,6 +224,15 @@ func (sf *Page) unmarshallStatus(r io.Reader) (err error) {
 	sf.Status = f.Value().(Status)
 	return
 }
+func (sf *Page) unmarshallLocation(r io.Reader) (err error) {
+	f := new(PageRef)
+	err = f.Unmarshall(r)
+	if err != nil {
+		return
+	}
+	sf.Location = f.Value().(PageRef)
+	return
+}
 func (sf *Page) Unmarshall(r io.Reader) (err error) {
 	if err = sf.unmarshallMeta(r); err != nil {
 		return
@@ -253,6 +264,9 @@ func (sf *Page) Unmarshall(r io.Reader) (err error) {
 	if err = sf.unmarshallStatus(r); err != nil {
 		return
 	}
+	if err = sf.unmarshallLocation(r); err != nil {
+		return
+	}
 	if err = sf.Transform(); err != nil {
 		return
 	}
@@ -340,5 +354,8 @@ func (sf Page) Marshall(w io.Writer) (err error) {
 	if err = sf.Status.Marshall(w); err != nil {
 		return
 	}
+	if err = sf.Location.Marshall(w); err != nil {
+		return
+	}
 	return
 }
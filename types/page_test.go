@@ -0,0 +1,86 @@
+package types
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	mint "github.com/vinyl-linux/mint"
+)
+
+func TestPage_FieldErrors(t *testing.T) {
+	ref := PageRef{Page: uuid.Must(uuid.NewV4())}
+
+	for _, test := range []struct {
+		name         string
+		page         Page
+		expectFields []string
+	}{
+		{"Valid page", Page{
+			Title:         "A Page",
+			Sections:      []Section{{Title: "One", Body: "see [l:0]"}, {Title: "Two"}},
+			Links:         []PageRef{ref},
+			Tags:          []string{"a"},
+			Labels:        map[string]string{"colour": "blue"},
+			Relationships: []Relationship{{Subject: ref, Predicate: PredicateExtends, Object: ref}},
+		}, nil},
+		{"Empty title", Page{}, []string{"Title"}},
+		{"Title too long", Page{Title: strings.Repeat("a", MaxTitleLength+1)}, []string{"Title"}},
+		{"Multibyte title at the limit", Page{Title: strings.Repeat("é", MaxTitleLength)}, nil},
+		{"Duplicate section titles", Page{Title: "A Page", Sections: []Section{{Title: "One"}, {Title: "Two"}, {Title: "One"}}}, []string{"Sections[2].Title"}},
+		{"Dangling links", Page{Title: "A Page", Links: []PageRef{ref}, Sections: []Section{{Title: "One", Body: "[l:0] [l:1] [l:2]"}}}, []string{"Sections[0].Body", "Sections[0].Body"}},
+		{"Escaped links", Page{Title: "A Page", Sections: []Section{{Title: "One", Body: "\\[l:0]"}}}, nil},
+		{"Links in code", Page{Title: "A Page", Sections: []Section{{Title: "One", Body: "`[l:0]`\n\n```\n[l:1]\n```\n"}}}, nil},
+		{"Too many tags", Page{Title: "A Page", Tags: strings.Fields(strings.Repeat("a ", MaxTags+1))}, []string{"Tags"}},
+		{"Bad tags", Page{Title: "A Page", Tags: []string{"", strings.Repeat("a", MaxTagLength+1)}}, []string{"Tags[0]", "Tags[1]"}},
+		{"Bad labels", Page{Title: "A Page", Labels: map[string]string{"": "a", "b": strings.Repeat("a", MaxLabelValueLength+1)}}, []string{"Labels[]", "Labels[b]"}},
+		{"Empty relationship", Page{Title: "A Page", Relationships: []Relationship{{}}}, []string{"Relationships[0].Predicate", "Relationships[0].Subject", "Relationships[0].Object"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			errs := test.page.FieldErrors()
+
+			fields := make([]string, len(errs))
+			for i, fe := range errs {
+				fields[i] = fe.Field
+			}
+
+			if !slices.Equal(fields, test.expectFields) {
+				t.Errorf("expected errors for %v, received %v", test.expectFields, errs)
+			}
+		})
+	}
+}
+
+func TestPage_Validate(t *testing.T) {
+	p := Page{Title: "A Page", Tags: []string{""}}
+
+	err := p.Validate()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if !strings.Contains(err.Error(), "Tags[0]: must not be empty") {
+		t.Errorf("expected error to name the field, received %v", err)
+	}
+
+	var fe FieldError
+	if !errors.As(err, &fe) || fe.Field != "Tags[0]" {
+		t.Errorf("expected FieldError for Tags[0], received %#v", err)
+	}
+
+	var fes FieldErrors
+	if !errors.As(err, &fes) || len(fes) != 1 {
+		t.Errorf("expected FieldErrors, received %#v", err)
+	}
+
+	if !errors.As(err, new(mint.ErrValidationErrors)) {
+		t.Errorf("expected mint.ErrValidationErrors, received %#v", err)
+	}
+
+	err = Page{Title: "A Page"}.Validate()
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
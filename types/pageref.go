@@ -19,7 +19,7 @@ func (sf PageRef) Validate() error {
 			errors = append(errors, err)
 		}
 	}
-	return mint.ValidationErrors("PageRef", errors)
+	return wrapValidationErrors(mint.ValidationErrors("PageRef", errors), errors)
 }
 func (sf *PageRef) Transform() (err error) {
 	return
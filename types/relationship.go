@@ -18,7 +18,7 @@ func (sf Relationship) Validate() error {
 			errors = append(errors, err)
 		}
 	}
-	return mint.ValidationErrors("Relationship", errors)
+	return wrapValidationErrors(mint.ValidationErrors("Relationship", errors), errors)
 }
 func (sf *Relationship) Transform() (err error) {
 	return
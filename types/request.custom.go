@@ -1 +1,19 @@
 package types
+
+// Args keys reserved by gordon itself
+const (
+	// ArgTraceparent is the W3C traceparent of the span a request was
+	// made from, so that traces carry on across servers
+	ArgTraceparent = "traceparent"
+
+	// ArgIfNoneMatch is the Revision of a copy of the requested page the
+	// client already has. Servers answer Reads for pages whose Revision
+	// still matches with a NotModified page
+	ArgIfNoneMatch = "if-none-match"
+
+	// ArgIfModifiedSince is the Published date, in RFC 3339 format, of a
+	// copy of the requested page the client already has. Servers answer
+	// Reads for pages which haven't been published since with a
+	// NotModified page
+	ArgIfModifiedSince = "if-modified-since"
+)
@@ -22,7 +22,7 @@ func (sf Request) Validate() error {
 			errors = append(errors, err)
 		}
 	}
-	return mint.ValidationErrors("Request", errors)
+	return wrapValidationErrors(mint.ValidationErrors("Request", errors), errors)
 }
 func (sf *Request) Transform() (err error) {
 	return
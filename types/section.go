@@ -7,7 +7,8 @@ import (
 
 type Section struct {
 	Title string
-	Body  string
+	// Body is the content of the section, as largely plain text with a little line based markup, specified by the markup package
+	Body string
 }
 
 func (sf Section) Validate() error {
@@ -17,7 +18,7 @@ func (sf Section) Validate() error {
 			errors = append(errors, err)
 		}
 	}
-	return mint.ValidationErrors("Section", errors)
+	return wrapValidationErrors(mint.ValidationErrors("Section", errors), errors)
 }
 func (sf *Section) Transform() (err error) {
 	return
@@ -0,0 +1,12 @@
+package types
+
+// IsError returns whether s marks a page as an error page, rather than as
+// a document, or as a pointer to one elsewhere
+func (s Status) IsError() bool {
+	switch s {
+	case StatusOK, StatusRedirect, StatusNotModified:
+		return false
+	}
+
+	return true
+}
@@ -12,10 +12,17 @@ const (
 	StatusUnknown Status = iota
 	StatusOK
 	StatusError
+	StatusNotFound
+	StatusForbidden
+	StatusBadRequest
+	StatusRedirect
+	StatusNotModified
+	StatusTooLarge
+	StatusUnavailable
 )
 
 func (sf Status) Marshall(w io.Writer) (err error) {
-	if sf < 1 || sf > 2 {
+	if sf < 1 || sf > 9 {
 		return errors.New("invalid value for type Status")
 	}
 	return mint.NewByteScalar(byte(sf)).Marshall(w)
@@ -27,7 +34,7 @@ func (sf *Status) Unmarshall(r io.Reader) (err error) {
 		return
 	}
 	*sf = Status(f.Value().(byte))
-	if *sf < 1 || *sf > 2 {
+	if *sf < 1 || *sf > 9 {
 		return errors.New("invalid value for type Status")
 	}
 	return
@@ -0,0 +1,118 @@
+package types
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Limits on the size of a Page, enforced by Page.Validate
+const (
+	MaxTitleLength      = 512
+	MaxTags             = 64
+	MaxTagLength        = 128
+	MaxLabels           = 64
+	MaxLabelKeyLength   = 128
+	MaxLabelValueLength = 1024
+)
+
+// A FieldError describes a single problem with a single field of a Page
+type FieldError struct {
+	// Field is the path to the field with the problem, such as
+	// Sections[2].Body, or Labels[colour]
+	Field string
+
+	// Message describes the problem
+	Message string
+}
+
+// Error fulfills the error interface
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// FieldErrors is a list of FieldError, returned where a Page fails
+// validation
+type FieldErrors []FieldError
+
+// Error fulfills the error interface
+func (e FieldErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns each FieldError, so that FieldErrors may be inspected with
+// errors.As
+func (e FieldErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, fe := range e {
+		errs[i] = fe
+	}
+
+	return errs
+}
+
+// err returns e as an error, or nil where e is empty, so that empty
+// FieldErrors don't end up as non-nil errors
+func (e FieldErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+func (e *FieldErrors) add(field, format string, args ...any) {
+	*e = append(*e, FieldError{
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// sortedKeys returns the keys of m in order, so that errors about maps are
+// reported in the same order every time
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+// validationError is the error generated Validate methods return, in place
+// of the mint.ErrValidationErrors it wraps. It reads the same, but can be
+// unwrapped to each of the errors it's made of, so that FieldErrors can be
+// inspected with errors.As
+type validationError struct {
+	err  error
+	errs []error
+}
+
+// Error fulfills the error interface
+func (e validationError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the mint.ErrValidationErrors e wraps, followed by each of
+// the errors it's made of
+func (e validationError) Unwrap() []error {
+	return append([]error{e.err}, e.errs...)
+}
+
+// wrapValidationErrors wraps err, the mint.ErrValidationErrors made of errs,
+// where there is one. Generated Validate methods are rewritten to call it by
+// the Makefile
+func wrapValidationErrors(err error, errs []error) error {
+	if err == nil {
+		return nil
+	}
+
+	return validationError{err: err, errs: errs}
+}